@@ -3,6 +3,7 @@ package heimdall
 import (
 	"math"
 	"math/rand/v2"
+	"sync"
 	"time"
 )
 
@@ -11,13 +12,53 @@ type Backoff interface {
 	Next(retry int) time.Duration
 }
 
+// JitterFunc returns a random number in [0, n), like rand.Int64N
+type JitterFunc func(n int64) int64
+
+// BackoffOption represents the backoff options
+type BackoffOption func(*backoffConfig)
+
+type backoffConfig struct {
+	jitter JitterFunc
+}
+
+// WithJitterSource makes the backoff draw its jitter from the given source, e.g. a seeded rand.PCG for
+// reproducible retry schedules. The source is guarded by a lock, as backoffs are shared by concurrent calls.
+func WithJitterSource(source rand.Source) BackoffOption {
+	var mu sync.Mutex
+	random := rand.New(source)
+
+	return WithJitterFunc(func(n int64) int64 {
+		mu.Lock()
+		defer mu.Unlock()
+		return random.Int64N(n)
+	})
+}
+
+// WithJitterFunc makes the backoff draw its jitter from the given function, which must be safe for concurrent use
+func WithJitterFunc(jitter JitterFunc) BackoffOption {
+	return func(c *backoffConfig) {
+		c.jitter = jitter
+	}
+}
+
+func newBackoffConfig(opts []BackoffOption) backoffConfig {
+	config := backoffConfig{jitter: rand.Int64N}
+	for _, opt := range opts {
+		opt(&config)
+	}
+
+	return config
+}
+
 type constantBackoff struct {
 	backoffInterval       int64
 	maximumJitterInterval int64
+	jitter                JitterFunc
 }
 
 // NewConstantBackoff returns an instance of ConstantBackoff
-func NewConstantBackoff(backoffInterval, maximumJitterInterval time.Duration) Backoff {
+func NewConstantBackoff(backoffInterval, maximumJitterInterval time.Duration, opts ...BackoffOption) Backoff {
 	// protect against panic when generating random jitter
 	if maximumJitterInterval < 0 {
 		maximumJitterInterval = 0
@@ -26,12 +67,13 @@ func NewConstantBackoff(backoffInterval, maximumJitterInterval time.Duration) Ba
 	return &constantBackoff{
 		backoffInterval:       int64(backoffInterval / time.Millisecond),
 		maximumJitterInterval: int64(maximumJitterInterval / time.Millisecond),
+		jitter:                newBackoffConfig(opts).jitter,
 	}
 }
 
 // Next returns next time for retrying operation with constant strategy
 func (cb *constantBackoff) Next(retry int) time.Duration {
-	return (time.Duration(cb.backoffInterval) * time.Millisecond) + (time.Duration(rand.Int64N(cb.maximumJitterInterval+1)) * time.Millisecond)
+	return (time.Duration(cb.backoffInterval) * time.Millisecond) + (time.Duration(cb.jitter(cb.maximumJitterInterval+1)) * time.Millisecond)
 }
 
 type exponentialBackoff struct {
@@ -39,10 +81,11 @@ type exponentialBackoff struct {
 	initialTimeout        float64
 	maxTimeout            float64
 	maximumJitterInterval int64
+	jitter                JitterFunc
 }
 
 // NewExponentialBackoff returns an instance of ExponentialBackoff
-func NewExponentialBackoff(initialTimeout, maxTimeout time.Duration, exponentFactor float64, maximumJitterInterval time.Duration) Backoff {
+func NewExponentialBackoff(initialTimeout, maxTimeout time.Duration, exponentFactor float64, maximumJitterInterval time.Duration, opts ...BackoffOption) Backoff {
 	// protect against panic when generating random jitter
 	if maximumJitterInterval < 0 {
 		maximumJitterInterval = 0
@@ -53,6 +96,7 @@ func NewExponentialBackoff(initialTimeout, maxTimeout time.Duration, exponentFac
 		initialTimeout:        float64(initialTimeout / time.Millisecond),
 		maxTimeout:            float64(maxTimeout / time.Millisecond),
 		maximumJitterInterval: int64(maximumJitterInterval / time.Millisecond),
+		jitter:                newBackoffConfig(opts).jitter,
 	}
 }
 
@@ -61,5 +105,177 @@ func (eb *exponentialBackoff) Next(retry int) time.Duration {
 	if retry < 0 {
 		retry = 0
 	}
-	return time.Duration(math.Min(eb.initialTimeout*math.Pow(eb.exponentFactor, float64(retry)), eb.maxTimeout)+float64(rand.Int64N(eb.maximumJitterInterval+1))) * time.Millisecond
+	return time.Duration(math.Min(eb.initialTimeout*math.Pow(eb.exponentFactor, float64(retry)), eb.maxTimeout)+float64(eb.jitter(eb.maximumJitterInterval+1))) * time.Millisecond
+}
+
+// Stop is returned by a backoff to signal that the call should not be retried any further
+const Stop time.Duration = -1
+
+// BackoffState describes the progress of the call a backoff is computed for
+type BackoffState struct {
+	Retry    int           // The zero based retry, as passed to Backoff.Next
+	Previous time.Duration // The previous backoff of the call, 0 before the first retry
+	Elapsed  time.Duration // The sum of the previous backoffs of the call
+}
+
+// StatefulBackoff is implemented by backoff strategies which depend on the progress of the call, e.g. the previous
+// backoff. As a Backoff is shared by concurrent calls, that progress is passed in rather than kept by the strategy.
+type StatefulBackoff interface {
+	Backoff
+	NextState(state BackoffState) time.Duration
+}
+
+type decorrelatedJitterBackoff struct {
+	baseInterval time.Duration
+	maxInterval  time.Duration
+	jitter       JitterFunc
+}
+
+// NewDecorrelatedJitterBackoff returns an instance of decorrelated jitter backoff, as described in
+// https://aws.amazon.com/blogs/architecture/exponential-backoff-and-jitter/.
+// Each backoff is picked at random between baseInterval and three times the previous backoff, capped at maxInterval.
+func NewDecorrelatedJitterBackoff(baseInterval, maxInterval time.Duration, opts ...BackoffOption) StatefulBackoff {
+	return &decorrelatedJitterBackoff{
+		baseInterval: baseInterval,
+		maxInterval:  max(maxInterval, baseInterval),
+		jitter:       newBackoffConfig(opts).jitter,
+	}
+}
+
+// Next returns next time for retrying operation with decorrelated jitter strategy.
+// Without the previous backoff, the upper bound grows as if every previous backoff had been the largest possible.
+func (db *decorrelatedJitterBackoff) Next(retry int) time.Duration {
+	upper := exponential(db.baseInterval, db.maxInterval, 3, max(retry, 0)+1)
+	return db.between(upper)
+}
+
+// NextState returns next time for retrying operation with decorrelated jitter strategy
+func (db *decorrelatedJitterBackoff) NextState(state BackoffState) time.Duration {
+	if state.Previous <= 0 {
+		return db.Next(state.Retry)
+	}
+
+	upper := time.Duration(math.Min(float64(max(state.Previous, db.baseInterval))*3, float64(db.maxInterval)))
+	return db.between(upper)
+}
+
+func (db *decorrelatedJitterBackoff) between(upper time.Duration) time.Duration {
+	return db.baseInterval + randomUpTo(db.jitter, upper-db.baseInterval)
+}
+
+type fullJitterBackoff struct {
+	initialTimeout time.Duration
+	maxTimeout     time.Duration
+	exponentFactor float64
+	jitter         JitterFunc
+}
+
+// NewFullJitterBackoff returns an instance of exponential backoff with full jitter, where each backoff is picked
+// at random between 0 and the exponential backoff.
+func NewFullJitterBackoff(initialTimeout, maxTimeout time.Duration, exponentFactor float64, opts ...BackoffOption) Backoff {
+	return &fullJitterBackoff{
+		initialTimeout: initialTimeout,
+		maxTimeout:     maxTimeout,
+		exponentFactor: exponentFactor,
+		jitter:         newBackoffConfig(opts).jitter,
+	}
+}
+
+// Next returns next time for retrying operation with full jitter strategy
+func (fb *fullJitterBackoff) Next(retry int) time.Duration {
+	return randomUpTo(fb.jitter, exponential(fb.initialTimeout, fb.maxTimeout, fb.exponentFactor, max(retry, 0)))
+}
+
+type equalJitterBackoff struct {
+	initialTimeout time.Duration
+	maxTimeout     time.Duration
+	exponentFactor float64
+	jitter         JitterFunc
+}
+
+// NewEqualJitterBackoff returns an instance of exponential backoff with equal jitter, where each backoff is
+// half of the exponential backoff plus a random value up to the other half.
+func NewEqualJitterBackoff(initialTimeout, maxTimeout time.Duration, exponentFactor float64, opts ...BackoffOption) Backoff {
+	return &equalJitterBackoff{
+		initialTimeout: initialTimeout,
+		maxTimeout:     maxTimeout,
+		exponentFactor: exponentFactor,
+		jitter:         newBackoffConfig(opts).jitter,
+	}
+}
+
+// Next returns next time for retrying operation with equal jitter strategy
+func (eb *equalJitterBackoff) Next(retry int) time.Duration {
+	half := exponential(eb.initialTimeout, eb.maxTimeout, eb.exponentFactor, max(retry, 0)) / 2
+	return half + randomUpTo(eb.jitter, half)
+}
+
+type linearBackoff struct {
+	initialTimeout        time.Duration
+	increment             time.Duration
+	maxTimeout            time.Duration
+	maximumJitterInterval time.Duration
+	jitter                JitterFunc
+}
+
+// NewLinearBackoff returns an instance of linear backoff, which grows by increment on each retry up to maxTimeout
+func NewLinearBackoff(initialTimeout, increment, maxTimeout, maximumJitterInterval time.Duration, opts ...BackoffOption) Backoff {
+	return &linearBackoff{
+		initialTimeout:        initialTimeout,
+		increment:             increment,
+		maxTimeout:            maxTimeout,
+		maximumJitterInterval: max(maximumJitterInterval, 0), // protect against panic when generating random jitter
+		jitter:                newBackoffConfig(opts).jitter,
+	}
+}
+
+// Next returns next time for retrying operation with linear strategy
+func (lb *linearBackoff) Next(retry int) time.Duration {
+	backoff := math.Min(float64(lb.initialTimeout)+float64(lb.increment)*float64(max(retry, 0)), float64(lb.maxTimeout))
+	return time.Duration(backoff) + randomUpTo(lb.jitter, lb.maximumJitterInterval)
+}
+
+type fibonacciBackoff struct {
+	initialTimeout        time.Duration
+	maxTimeout            time.Duration
+	maximumJitterInterval time.Duration
+	jitter                JitterFunc
+}
+
+// NewFibonacciBackoff returns an instance of Fibonacci backoff, which grows as 1, 2, 3, 5, 8... times
+// initialTimeout up to maxTimeout
+func NewFibonacciBackoff(initialTimeout, maxTimeout, maximumJitterInterval time.Duration, opts ...BackoffOption) Backoff {
+	return &fibonacciBackoff{
+		initialTimeout:        initialTimeout,
+		maxTimeout:            maxTimeout,
+		maximumJitterInterval: max(maximumJitterInterval, 0), // protect against panic when generating random jitter
+		jitter:                newBackoffConfig(opts).jitter,
+	}
+}
+
+// Next returns next time for retrying operation with Fibonacci strategy
+func (fb *fibonacciBackoff) Next(retry int) time.Duration {
+	previous, current := fb.initialTimeout, fb.initialTimeout
+	for range max(retry, 0) {
+		if current >= fb.maxTimeout {
+			break
+		}
+		previous, current = current, previous+current
+	}
+
+	return min(current, fb.maxTimeout) + randomUpTo(fb.jitter, fb.maximumJitterInterval)
+}
+
+// exponential returns initial*factor^retry capped at maximum
+func exponential(initial, maximum time.Duration, factor float64, retry int) time.Duration {
+	return time.Duration(math.Min(float64(initial)*math.Pow(factor, float64(retry)), float64(maximum)))
+}
+
+// randomUpTo returns a random duration between 0 and n, both inclusive
+func randomUpTo(jitter JitterFunc, n time.Duration) time.Duration {
+	if n <= 0 {
+		return 0
+	}
+
+	return time.Duration(jitter(int64(n) + 1))
 }
@@ -0,0 +1,115 @@
+package heimdall
+
+import "time"
+
+type cappedBackoff struct {
+	backoff     Backoff
+	maxInterval time.Duration
+}
+
+// WithCap returns a backoff which never waits longer than maxInterval between retries
+func WithCap(backoff Backoff, maxInterval time.Duration) StatefulBackoff {
+	return &cappedBackoff{backoff: backoff, maxInterval: maxInterval}
+}
+
+// Next returns the next time of the wrapped backoff, capped at maxInterval
+func (cb *cappedBackoff) Next(retry int) time.Duration {
+	return cb.NextState(BackoffState{Retry: retry})
+}
+
+// NextState returns the next time of the wrapped backoff, capped at maxInterval
+func (cb *cappedBackoff) NextState(state BackoffState) time.Duration {
+	next := nextState(cb.backoff, state)
+	if next == Stop {
+		return Stop
+	}
+
+	return min(next, cb.maxInterval)
+}
+
+type flooredBackoff struct {
+	backoff     Backoff
+	minInterval time.Duration
+}
+
+// WithFloor returns a backoff which always waits at least minInterval between retries
+func WithFloor(backoff Backoff, minInterval time.Duration) StatefulBackoff {
+	return &flooredBackoff{backoff: backoff, minInterval: minInterval}
+}
+
+// Next returns the next time of the wrapped backoff, raised to minInterval
+func (fb *flooredBackoff) Next(retry int) time.Duration {
+	return fb.NextState(BackoffState{Retry: retry})
+}
+
+// NextState returns the next time of the wrapped backoff, raised to minInterval
+func (fb *flooredBackoff) NextState(state BackoffState) time.Duration {
+	next := nextState(fb.backoff, state)
+	if next == Stop {
+		return Stop
+	}
+
+	return max(next, fb.minInterval)
+}
+
+type maxElapsedBackoff struct {
+	backoff    Backoff
+	maxElapsed time.Duration
+}
+
+// WithMaxElapsed returns a backoff which returns Stop once the backoffs of a call would add up to more than maxElapsed.
+// As Next doesn't know how long the call has waited already, the budget only applies through NextState.
+func WithMaxElapsed(backoff Backoff, maxElapsed time.Duration) StatefulBackoff {
+	return &maxElapsedBackoff{backoff: backoff, maxElapsed: maxElapsed}
+}
+
+// Next returns the next time of the wrapped backoff
+func (mb *maxElapsedBackoff) Next(retry int) time.Duration {
+	return mb.NextState(BackoffState{Retry: retry})
+}
+
+// NextState returns the next time of the wrapped backoff, or Stop if it would exceed maxElapsed
+func (mb *maxElapsedBackoff) NextState(state BackoffState) time.Duration {
+	next := nextState(mb.backoff, state)
+	if next == Stop || state.Elapsed+next > mb.maxElapsed {
+		return Stop
+	}
+
+	return next
+}
+
+type sequenceBackoff struct {
+	first   Backoff
+	retries int
+	then    Backoff
+}
+
+// Sequence returns a backoff which uses first for the given number of retries, then switches to then.
+// The retries passed to then start over from 0.
+func Sequence(first Backoff, retries int, then Backoff) StatefulBackoff {
+	return &sequenceBackoff{first: first, retries: retries, then: then}
+}
+
+// Next returns the next time of the backoff in use for the retry
+func (sb *sequenceBackoff) Next(retry int) time.Duration {
+	return sb.NextState(BackoffState{Retry: retry})
+}
+
+// NextState returns the next time of the backoff in use for the retry
+func (sb *sequenceBackoff) NextState(state BackoffState) time.Duration {
+	if state.Retry < sb.retries {
+		return nextState(sb.first, state)
+	}
+
+	state.Retry -= sb.retries
+	return nextState(sb.then, state)
+}
+
+// nextState returns the next time of the backoff, passing the state to stateful backoffs
+func nextState(backoff Backoff, state BackoffState) time.Duration {
+	if sb, ok := backoff.(StatefulBackoff); ok {
+		return sb.NextState(state)
+	}
+
+	return backoff.Next(state.Retry)
+}
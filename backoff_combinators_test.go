@@ -0,0 +1,81 @@
+package heimdall
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithCap(t *testing.T) {
+	t.Parallel()
+
+	backoff := WithCap(NewExponentialBackoff(100*time.Millisecond, 10*time.Second, 2.0, 0), 300*time.Millisecond)
+
+	assert.Equal(t, 100*time.Millisecond, backoff.Next(0))
+	assert.Equal(t, 200*time.Millisecond, backoff.Next(1))
+	assert.Equal(t, 300*time.Millisecond, backoff.Next(2))
+	assert.Equal(t, 300*time.Millisecond, backoff.NextState(BackoffState{Retry: 5}))
+}
+
+func TestWithFloor(t *testing.T) {
+	t.Parallel()
+
+	backoff := WithFloor(NewFullJitterBackoff(100*time.Millisecond, time.Second, 2.0), 50*time.Millisecond)
+
+	for range 10000 {
+		next := backoff.Next(0)
+		assert.True(t, 50*time.Millisecond <= next && next <= 100*time.Millisecond, next)
+	}
+}
+
+func TestWithMaxElapsed(t *testing.T) {
+	t.Parallel()
+
+	backoff := WithMaxElapsed(NewConstantBackoff(100*time.Millisecond, 0), 250*time.Millisecond)
+
+	assert.Equal(t, 100*time.Millisecond, backoff.NextState(BackoffState{Retry: 0}))
+	assert.Equal(t, 100*time.Millisecond, backoff.NextState(BackoffState{Retry: 1, Elapsed: 100 * time.Millisecond}))
+	assert.Equal(t, Stop, backoff.NextState(BackoffState{Retry: 2, Elapsed: 200 * time.Millisecond}))
+	assert.Equal(t, 100*time.Millisecond, backoff.Next(2), "Next doesn't know the elapsed time")
+}
+
+func TestSequence(t *testing.T) {
+	t.Parallel()
+
+	backoff := Sequence(
+		NewConstantBackoff(10*time.Millisecond, 0), 2,
+		NewExponentialBackoff(100*time.Millisecond, time.Second, 2.0, 0),
+	)
+
+	assert.Equal(t, 10*time.Millisecond, backoff.Next(0))
+	assert.Equal(t, 10*time.Millisecond, backoff.Next(1))
+	assert.Equal(t, 100*time.Millisecond, backoff.Next(2))
+	assert.Equal(t, 200*time.Millisecond, backoff.Next(3))
+	assert.Equal(t, 400*time.Millisecond, backoff.NextState(BackoffState{Retry: 4}))
+}
+
+func TestCombinatorsPassStateThrough(t *testing.T) {
+	t.Parallel()
+
+	backoff := WithCap(
+		Sequence(NewConstantBackoff(0, 0), 1, NewDecorrelatedJitterBackoff(10*time.Millisecond, time.Second)),
+		time.Second,
+	)
+
+	for range 1000 {
+		next := backoff.NextState(BackoffState{Retry: 3, Previous: 20 * time.Millisecond})
+		assert.True(t, 10*time.Millisecond <= next && next <= 60*time.Millisecond, next)
+	}
+}
+
+func TestCombinatorsPassStopThrough(t *testing.T) {
+	t.Parallel()
+
+	stopped := WithMaxElapsed(NewConstantBackoff(100*time.Millisecond, 0), 0)
+	state := BackoffState{Retry: 1, Elapsed: time.Second}
+
+	assert.Equal(t, Stop, WithCap(stopped, time.Second).NextState(state))
+	assert.Equal(t, Stop, WithFloor(stopped, time.Second).NextState(state))
+	assert.Equal(t, Stop, Sequence(stopped, 5, NewConstantBackoff(0, 0)).NextState(state))
+}
@@ -1,6 +1,7 @@
 package heimdall
 
 import (
+	"math/rand/v2"
 	"testing"
 	"time"
 
@@ -136,3 +137,175 @@ func TestConstantBackoffJitter50(t *testing.T) {
 		assert.True(t, 100*time.Millisecond <= constantBackoff.Next(i) && constantBackoff.Next(1) <= 150*time.Millisecond)
 	}
 }
+
+// sample returns n backoffs for the given retry along with their mean
+func sample(n int, next func() time.Duration) ([]time.Duration, time.Duration) {
+	samples := make([]time.Duration, n)
+	var sum time.Duration
+	for i := range samples {
+		samples[i] = next()
+		sum += samples[i]
+	}
+
+	return samples, sum / time.Duration(n)
+}
+
+func TestFullJitterBackoffDistribution(t *testing.T) {
+	t.Parallel()
+
+	fullJitterBackoff := NewFullJitterBackoff(100*time.Millisecond, 1000*time.Millisecond, 2.0)
+
+	for retry, upper := range []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond, 800 * time.Millisecond, 1000 * time.Millisecond} {
+		samples, mean := sample(10000, func() time.Duration { return fullJitterBackoff.Next(retry) })
+		for _, backoff := range samples {
+			assert.True(t, 0 <= backoff && backoff <= upper, "retry %d: %s", retry, backoff)
+		}
+		assert.InDelta(t, upper/2, mean, float64(upper)/20, "retry %d", retry)
+	}
+}
+
+func TestEqualJitterBackoffDistribution(t *testing.T) {
+	t.Parallel()
+
+	equalJitterBackoff := NewEqualJitterBackoff(100*time.Millisecond, 1000*time.Millisecond, 2.0)
+
+	for retry, upper := range []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond, 800 * time.Millisecond, 1000 * time.Millisecond} {
+		samples, mean := sample(10000, func() time.Duration { return equalJitterBackoff.Next(retry) })
+		for _, backoff := range samples {
+			assert.True(t, upper/2 <= backoff && backoff <= upper, "retry %d: %s", retry, backoff)
+		}
+		assert.InDelta(t, upper*3/4, mean, float64(upper)/20, "retry %d", retry)
+	}
+}
+
+func TestDecorrelatedJitterBackoffDistribution(t *testing.T) {
+	t.Parallel()
+
+	decorrelatedJitterBackoff := NewDecorrelatedJitterBackoff(100*time.Millisecond, 1000*time.Millisecond)
+
+	samples, mean := sample(10000, func() time.Duration {
+		return decorrelatedJitterBackoff.NextState(BackoffState{Retry: 3, Previous: 200 * time.Millisecond})
+	})
+	for _, backoff := range samples {
+		assert.True(t, 100*time.Millisecond <= backoff && backoff <= 600*time.Millisecond, backoff)
+	}
+	assert.InDelta(t, 350*time.Millisecond, mean, float64(25*time.Millisecond))
+
+	samples, _ = sample(10000, func() time.Duration {
+		return decorrelatedJitterBackoff.NextState(BackoffState{Retry: 5, Previous: 900 * time.Millisecond})
+	})
+	for _, backoff := range samples {
+		assert.True(t, 100*time.Millisecond <= backoff && backoff <= 1000*time.Millisecond, backoff)
+	}
+}
+
+func TestDecorrelatedJitterBackoffWithoutPreviousBackoff(t *testing.T) {
+	t.Parallel()
+
+	decorrelatedJitterBackoff := NewDecorrelatedJitterBackoff(100*time.Millisecond, 1000*time.Millisecond)
+
+	for retry, upper := range []time.Duration{300 * time.Millisecond, 900 * time.Millisecond, 1000 * time.Millisecond} {
+		samples, _ := sample(10000, func() time.Duration { return decorrelatedJitterBackoff.Next(retry) })
+		for _, backoff := range samples {
+			assert.True(t, 100*time.Millisecond <= backoff && backoff <= upper, "retry %d: %s", retry, backoff)
+		}
+	}
+
+	first := decorrelatedJitterBackoff.NextState(BackoffState{})
+	assert.True(t, 100*time.Millisecond <= first && first <= 300*time.Millisecond, first)
+}
+
+func TestLinearBackoffNextTime(t *testing.T) {
+	t.Parallel()
+
+	linearBackoff := NewLinearBackoff(100*time.Millisecond, 50*time.Millisecond, 250*time.Millisecond, -1*time.Millisecond)
+
+	assert.Equal(t, 100*time.Millisecond, linearBackoff.Next(-1))
+	assert.Equal(t, 100*time.Millisecond, linearBackoff.Next(0))
+	assert.Equal(t, 150*time.Millisecond, linearBackoff.Next(1))
+	assert.Equal(t, 200*time.Millisecond, linearBackoff.Next(2))
+	assert.Equal(t, 250*time.Millisecond, linearBackoff.Next(3))
+	assert.Equal(t, 250*time.Millisecond, linearBackoff.Next(100))
+}
+
+func TestLinearBackoffJitterDistribution(t *testing.T) {
+	t.Parallel()
+
+	linearBackoff := NewLinearBackoff(100*time.Millisecond, 50*time.Millisecond, 250*time.Millisecond, 20*time.Millisecond)
+
+	samples, mean := sample(10000, func() time.Duration { return linearBackoff.Next(1) })
+	for _, backoff := range samples {
+		assert.True(t, 150*time.Millisecond <= backoff && backoff <= 170*time.Millisecond, backoff)
+	}
+	assert.InDelta(t, 160*time.Millisecond, mean, float64(time.Millisecond))
+}
+
+func TestFibonacciBackoffNextTime(t *testing.T) {
+	t.Parallel()
+
+	fibonacciBackoff := NewFibonacciBackoff(100*time.Millisecond, 1000*time.Millisecond, 0)
+
+	assert.Equal(t, 100*time.Millisecond, fibonacciBackoff.Next(-1))
+	assert.Equal(t, 100*time.Millisecond, fibonacciBackoff.Next(0))
+	assert.Equal(t, 200*time.Millisecond, fibonacciBackoff.Next(1))
+	assert.Equal(t, 300*time.Millisecond, fibonacciBackoff.Next(2))
+	assert.Equal(t, 500*time.Millisecond, fibonacciBackoff.Next(3))
+	assert.Equal(t, 800*time.Millisecond, fibonacciBackoff.Next(4))
+	assert.Equal(t, 1000*time.Millisecond, fibonacciBackoff.Next(5))
+	assert.Equal(t, 1000*time.Millisecond, fibonacciBackoff.Next(1000))
+}
+
+func TestFibonacciBackoffJitterDistribution(t *testing.T) {
+	t.Parallel()
+
+	fibonacciBackoff := NewFibonacciBackoff(100*time.Millisecond, 1000*time.Millisecond, 20*time.Millisecond)
+
+	samples, mean := sample(10000, func() time.Duration { return fibonacciBackoff.Next(3) })
+	for _, backoff := range samples {
+		assert.True(t, 500*time.Millisecond <= backoff && backoff <= 520*time.Millisecond, backoff)
+	}
+	assert.InDelta(t, 510*time.Millisecond, mean, float64(time.Millisecond))
+}
+
+func TestJitterBackoffsWithLargeRetries(t *testing.T) {
+	t.Parallel()
+
+	for _, backoff := range []Backoff{
+		NewFullJitterBackoff(100*time.Millisecond, time.Second, 2.0),
+		NewEqualJitterBackoff(100*time.Millisecond, time.Second, 2.0),
+		NewDecorrelatedJitterBackoff(100*time.Millisecond, time.Second),
+	} {
+		next := backoff.Next(10000)
+		assert.True(t, 0 <= next && next <= time.Second, next)
+	}
+}
+
+func TestBackoffsWithJitterFunc(t *testing.T) {
+	t.Parallel()
+
+	half := WithJitterFunc(func(n int64) int64 { return n / 2 })
+
+	assert.Equal(t, 125*time.Millisecond, NewConstantBackoff(100*time.Millisecond, 50*time.Millisecond, half).Next(0))
+	assert.Equal(t, 225*time.Millisecond, NewExponentialBackoff(100*time.Millisecond, time.Second, 2, 50*time.Millisecond, half).Next(1))
+	assert.Equal(t, 100*time.Millisecond, NewFullJitterBackoff(100*time.Millisecond, time.Second, 2, half).Next(1))
+	assert.Equal(t, 150*time.Millisecond, NewEqualJitterBackoff(100*time.Millisecond, time.Second, 2, half).Next(1))
+	assert.Equal(t, 200*time.Millisecond, NewDecorrelatedJitterBackoff(100*time.Millisecond, time.Second, half).Next(0))
+	assert.Equal(t, 160*time.Millisecond, NewLinearBackoff(100*time.Millisecond, 50*time.Millisecond, time.Second, 20*time.Millisecond, half).Next(1))
+	assert.Equal(t, 310*time.Millisecond, NewFibonacciBackoff(100*time.Millisecond, time.Second, 20*time.Millisecond, half).Next(2))
+}
+
+func TestBackoffsWithJitterSourceAreReproducible(t *testing.T) {
+	t.Parallel()
+
+	schedule := func() []time.Duration {
+		backoff := NewExponentialBackoff(100*time.Millisecond, time.Second, 2, 100*time.Millisecond, WithJitterSource(rand.NewPCG(42, 7)))
+
+		intervals := make([]time.Duration, 5)
+		for retry := range intervals {
+			intervals[retry] = backoff.Next(retry)
+		}
+		return intervals
+	}
+
+	assert.Equal(t, schedule(), schedule())
+}
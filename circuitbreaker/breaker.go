@@ -0,0 +1,155 @@
+package circuitbreaker
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/gojek/heimdall/v8"
+)
+
+// ErrCircuitOpen is returned for the calls rejected by an open breaker, or by a half-open breaker
+// whose probe calls are all taken
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// State is the state of a breaker
+type State int
+
+const (
+	// StateClosed lets calls through, opening once too many of them fail or are slow
+	StateClosed State = iota
+	// StateOpen rejects calls, until it's time to probe whether the server recovered
+	StateOpen
+	// StateHalfOpen lets a number of probe calls through, closing if they succeed and opening again otherwise
+	StateHalfOpen
+)
+
+// String returns the name of the state
+func (s State) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateOpen:
+		return "open"
+	case StateHalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+// breaker is a circuit breaker, tracking the outcomes of calls in a sliding window
+type breaker struct {
+	clock                heimdall.Clock
+	minimumCalls         int
+	failureRateThreshold float64
+	slowCallDuration     time.Duration
+	slowCallRate         float64
+	openDuration         time.Duration
+	halfOpenProbes       int
+
+	mu         sync.Mutex
+	state      State
+	generation uint64 // incremented on every change of state, to ignore the outcomes of calls let through before
+	window     window
+	openedAt   time.Time
+	probes     int    // the probe calls let through while half-open
+	probed     counts // the outcomes of the probe calls
+}
+
+// allow returns a function to call with the outcome of the call, or ErrCircuitOpen if the call is rejected
+func (b *breaker) allow() (func(failure bool, duration time.Duration), error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.clock.Now()
+	b.checkOpenDuration(now)
+
+	switch b.state {
+	case StateOpen:
+		return nil, ErrCircuitOpen
+	case StateHalfOpen:
+		if b.probes >= b.halfOpenProbes {
+			return nil, ErrCircuitOpen
+		}
+		b.probes++
+	}
+
+	generation := b.generation
+	return func(failure bool, duration time.Duration) {
+		b.record(generation, failure, duration)
+	}, nil
+}
+
+func (b *breaker) currentState() State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.checkOpenDuration(b.clock.Now())
+	return b.state
+}
+
+func (b *breaker) record(generation uint64, failure bool, duration time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if generation != b.generation {
+		return // the call was let through in a previous state
+	}
+
+	now := b.clock.Now()
+	slow := b.slowCallDuration > 0 && duration >= b.slowCallDuration
+
+	switch b.state {
+	case StateClosed:
+		b.window.record(now, failure, slow)
+		if c := b.window.counts(now); c.calls >= b.minimumCalls && b.exceedsThresholds(c) {
+			b.transition(StateOpen, now)
+		}
+	case StateHalfOpen:
+		b.probed.add(failure, slow)
+		if b.probed.calls < b.halfOpenProbes {
+			return
+		}
+		if b.exceedsThresholds(b.probed) {
+			b.transition(StateOpen, now)
+			return
+		}
+		b.transition(StateClosed, now)
+	}
+}
+
+func (b *breaker) exceedsThresholds(c counts) bool {
+	if c.calls == 0 {
+		return false
+	}
+
+	failureRate := 100 * float64(c.failures) / float64(c.calls)
+	if failureRate >= b.failureRateThreshold {
+		return true
+	}
+
+	slowRate := 100 * float64(c.slow) / float64(c.calls)
+	return b.slowCallDuration > 0 && slowRate >= b.slowCallRate
+}
+
+// checkOpenDuration moves an open breaker to half-open once it has been open long enough
+func (b *breaker) checkOpenDuration(now time.Time) {
+	if b.state == StateOpen && !now.Before(b.openedAt.Add(b.openDuration)) {
+		b.transition(StateHalfOpen, now)
+	}
+}
+
+func (b *breaker) transition(state State, now time.Time) {
+	b.state = state
+	b.generation++
+	b.probes = 0
+	b.probed = counts{}
+
+	switch state {
+	case StateOpen:
+		b.openedAt = now
+	case StateClosed:
+		b.window.reset()
+	}
+}
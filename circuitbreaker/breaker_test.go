@@ -0,0 +1,164 @@
+package circuitbreaker
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gojek/heimdall/v8/heimdalltest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestBreaker(clock *heimdalltest.FakeClock) *breaker {
+	return &breaker{
+		clock:                clock,
+		window:               newCountWindow(4),
+		minimumCalls:         4,
+		failureRateThreshold: 50,
+		slowCallRate:         100,
+		openDuration:         time.Second,
+		halfOpenProbes:       2,
+	}
+}
+
+// call makes a call through the breaker, lasting the given duration
+func call(t *testing.T, b *breaker, clock *heimdalltest.FakeClock, failure bool, duration time.Duration) {
+	t.Helper()
+
+	done, err := b.allow()
+	require.NoError(t, err)
+	clock.Advance(duration)
+	done(failure, duration)
+}
+
+func TestBreakerOpensOnFailureRate(t *testing.T) {
+	t.Parallel()
+
+	clock := heimdalltest.NewFakeClock(time.Unix(0, 0))
+	b := newTestBreaker(clock)
+
+	call(t, b, clock, true, 0)
+	call(t, b, clock, true, 0)
+	call(t, b, clock, false, 0)
+	assert.Equal(t, StateClosed, b.currentState(), "should wait for the minimum number of calls")
+
+	call(t, b, clock, false, 0)
+	assert.Equal(t, StateOpen, b.currentState())
+
+	_, err := b.allow()
+	assert.ErrorIs(t, err, ErrCircuitOpen)
+}
+
+func TestBreakerStaysClosedBelowFailureRate(t *testing.T) {
+	t.Parallel()
+
+	clock := heimdalltest.NewFakeClock(time.Unix(0, 0))
+	b := newTestBreaker(clock)
+
+	for range 10 {
+		call(t, b, clock, true, 0)
+		call(t, b, clock, false, 0)
+		call(t, b, clock, false, 0)
+		call(t, b, clock, false, 0)
+	}
+	assert.Equal(t, StateClosed, b.currentState())
+}
+
+func TestBreakerOpensOnSlowCallRate(t *testing.T) {
+	t.Parallel()
+
+	clock := heimdalltest.NewFakeClock(time.Unix(0, 0))
+	b := newTestBreaker(clock)
+	b.slowCallDuration = 100 * time.Millisecond
+	b.slowCallRate = 75
+
+	call(t, b, clock, false, 200*time.Millisecond)
+	call(t, b, clock, false, 100*time.Millisecond)
+	call(t, b, clock, false, 10*time.Millisecond)
+	call(t, b, clock, false, 10*time.Millisecond)
+	assert.Equal(t, StateClosed, b.currentState())
+
+	call(t, b, clock, false, 150*time.Millisecond)
+	call(t, b, clock, false, 150*time.Millisecond)
+	assert.Equal(t, StateClosed, b.currentState(), "the first slow calls should have left the window")
+
+	call(t, b, clock, false, 150*time.Millisecond)
+	assert.Equal(t, StateOpen, b.currentState())
+}
+
+func TestBreakerClosesAfterSuccessfulProbes(t *testing.T) {
+	t.Parallel()
+
+	clock := heimdalltest.NewFakeClock(time.Unix(0, 0))
+	b := newTestBreaker(clock)
+	for range 4 {
+		call(t, b, clock, true, 0)
+	}
+	require.Equal(t, StateOpen, b.currentState())
+
+	clock.Advance(999 * time.Millisecond)
+	assert.Equal(t, StateOpen, b.currentState())
+	clock.Advance(time.Millisecond)
+	assert.Equal(t, StateHalfOpen, b.currentState())
+
+	first, err := b.allow()
+	require.NoError(t, err)
+	second, err := b.allow()
+	require.NoError(t, err)
+	_, err = b.allow()
+	assert.ErrorIs(t, err, ErrCircuitOpen, "only the probe calls should be let through")
+
+	first(false, 0)
+	assert.Equal(t, StateHalfOpen, b.currentState(), "should wait for every probe call")
+	second(false, 0)
+	assert.Equal(t, StateClosed, b.currentState())
+
+	call(t, b, clock, true, 0)
+	assert.Equal(t, StateClosed, b.currentState(), "the window should start over once closed")
+}
+
+func TestBreakerReopensAfterFailedProbes(t *testing.T) {
+	t.Parallel()
+
+	clock := heimdalltest.NewFakeClock(time.Unix(0, 0))
+	b := newTestBreaker(clock)
+	for range 4 {
+		call(t, b, clock, true, 0)
+	}
+	clock.Advance(time.Second)
+	require.Equal(t, StateHalfOpen, b.currentState())
+
+	call(t, b, clock, true, 0)
+	call(t, b, clock, false, 0)
+	assert.Equal(t, StateOpen, b.currentState())
+
+	clock.Advance(time.Second)
+	assert.Equal(t, StateHalfOpen, b.currentState(), "should probe again after the open duration")
+}
+
+func TestBreakerIgnoresCallsLetThroughInAPreviousState(t *testing.T) {
+	t.Parallel()
+
+	clock := heimdalltest.NewFakeClock(time.Unix(0, 0))
+	b := newTestBreaker(clock)
+
+	late, err := b.allow()
+	require.NoError(t, err)
+	for range 4 {
+		call(t, b, clock, true, 0)
+	}
+	clock.Advance(time.Second)
+	require.Equal(t, StateHalfOpen, b.currentState())
+
+	late(false, 0)
+	assert.Equal(t, StateHalfOpen, b.currentState(), "a call from the closed state isn't a probe")
+}
+
+func TestStateString(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "closed", StateClosed.String())
+	assert.Equal(t, "open", StateOpen.String())
+	assert.Equal(t, "half-open", StateHalfOpen.String())
+	assert.Equal(t, "unknown", State(42).String())
+}
@@ -0,0 +1,232 @@
+package circuitbreaker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gojek/heimdall/v8"
+	"github.com/gojek/heimdall/v8/httpclient"
+	"github.com/gojek/heimdall/v8/internal"
+)
+
+// Client is an http client guarded by a circuit breaker. Unlike the hystrix client, its breaker is local to
+// the client and runs on the client's clock, so its behaviour can be tested deterministically.
+// Every call of the wrapped http client, along with its retries, counts as one call for the breaker.
+type Client struct {
+	client    *httpclient.Client
+	breaker   breaker
+	isFailure func(*http.Response, error) bool
+
+	countWindowSize int
+	timeWindow      time.Duration
+
+	plugins            internal.PluginRegistry
+	pluginPanicHandler heimdall.PluginPanicHandler
+}
+
+const (
+	defaultCountWindowSize      = 100
+	defaultMinimumCalls         = 10
+	defaultFailureRateThreshold = 50
+	defaultOpenDuration         = 5 * time.Second
+	defaultHalfOpenProbes       = 5
+)
+
+var _ heimdall.Client = (*Client)(nil)
+
+// NewClient returns a new instance of circuit breaker Client
+func NewClient(opts ...Option) *Client {
+	client := Client{
+		client: httpclient.NewClient(),
+		breaker: breaker{
+			clock:                heimdall.NewSystemClock(),
+			minimumCalls:         defaultMinimumCalls,
+			failureRateThreshold: defaultFailureRateThreshold,
+			slowCallRate:         100,
+			openDuration:         defaultOpenDuration,
+			halfOpenProbes:       defaultHalfOpenProbes,
+		},
+		isFailure:       DefaultFailurePredicate,
+		countWindowSize: defaultCountWindowSize,
+	}
+
+	for _, opt := range opts {
+		opt(&client)
+	}
+
+	client.breaker.window = newCountWindow(client.countWindowSize)
+	if client.timeWindow > 0 {
+		client.breaker.window = newTimeWindow(client.timeWindow)
+	}
+
+	if client.pluginPanicHandler != nil {
+		httpclient.WithPluginPanicHandler(client.pluginPanicHandler)(client.client)
+	}
+
+	return &client
+}
+
+// DefaultFailurePredicate counts the calls failing with an error, except those canceled by the caller,
+// and the calls ending with a 5xx response as failures
+func DefaultFailurePredicate(response *http.Response, err error) bool {
+	if err != nil {
+		return !errors.Is(err, context.Canceled)
+	}
+
+	return response.StatusCode >= http.StatusInternalServerError
+}
+
+// State returns the current state of the breaker
+func (c *Client) State() State {
+	return c.breaker.currentState()
+}
+
+// Get makes a HTTP GET request to provided URL
+func (c *Client) Get(url string, headers http.Header) (*http.Response, error) {
+	var response *http.Response
+	request, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return response, fmt.Errorf("GET - request creation failed: %w", err)
+	}
+
+	request.Header = headers
+
+	return c.Do(request)
+}
+
+// Post makes a HTTP POST request to provided URL and requestBody
+func (c *Client) Post(url string, body io.Reader, headers http.Header) (*http.Response, error) {
+	var response *http.Response
+	request, err := http.NewRequest(http.MethodPost, url, body)
+	if err != nil {
+		return response, fmt.Errorf("POST - request creation failed: %w", err)
+	}
+
+	request.Header = headers
+
+	return c.Do(request)
+}
+
+// Put makes a HTTP PUT request to provided URL and requestBody
+func (c *Client) Put(url string, body io.Reader, headers http.Header) (*http.Response, error) {
+	var response *http.Response
+	request, err := http.NewRequest(http.MethodPut, url, body)
+	if err != nil {
+		return response, fmt.Errorf("PUT - request creation failed: %w", err)
+	}
+
+	request.Header = headers
+
+	return c.Do(request)
+}
+
+// Patch makes a HTTP PATCH request to provided URL and requestBody
+func (c *Client) Patch(url string, body io.Reader, headers http.Header) (*http.Response, error) {
+	var response *http.Response
+	request, err := http.NewRequest(http.MethodPatch, url, body)
+	if err != nil {
+		return response, fmt.Errorf("PATCH - request creation failed: %w", err)
+	}
+
+	request.Header = headers
+
+	return c.Do(request)
+}
+
+// Delete makes a HTTP DELETE request with provided URL
+func (c *Client) Delete(url string, headers http.Header) (*http.Response, error) {
+	var response *http.Response
+	request, err := http.NewRequest(http.MethodDelete, url, nil)
+	if err != nil {
+		return response, fmt.Errorf("DELETE - request creation failed: %w", err)
+	}
+
+	request.Header = headers
+
+	return c.Do(request)
+}
+
+// Do makes an HTTP request with the native `http.Do` interface, unless the breaker rejects it
+// with ErrCircuitOpen
+func (c *Client) Do(request *http.Request) (*http.Response, error) {
+	done, err := c.breaker.allow()
+	if err != nil {
+		if request.Body != nil {
+			_ = request.Body.Close()
+		}
+		c.reportRejected(request, err)
+		return nil, err
+	}
+
+	start := c.breaker.clock.Now()
+	failure := true // unless the call returns, e.g. if a plugin panics
+	defer func() {
+		done(failure, c.breaker.clock.Now().Sub(start))
+	}()
+
+	response, err := c.client.Do(request)
+	failure = c.isFailure(response, err)
+
+	return response, err
+}
+
+// AddPlugin Adds plugin to client
+func (c *Client) AddPlugin(p heimdall.Plugin) {
+	c.RegisterPlugin(p, 0)
+}
+
+// RegisterPlugin adds a plugin to the client with the given priority, plugins with a higher priority being
+// called first. Plugins added with AddPlugin have a priority of 0. The returned handle removes the plugin.
+// Plugins can be registered and removed while the client is in use.
+func (c *Client) RegisterPlugin(p heimdall.Plugin, priority int) heimdall.PluginHandle {
+	// the calls let through are reported by the http client, the calls rejected by the breaker by this client
+	return pluginHandles{
+		c.plugins.Add(p, priority),
+		c.client.RegisterPlugin(p, priority),
+	}
+}
+
+// RemovePlugin removes a plugin from the client, returning false if it wasn't added.
+// Plugins whose type isn't comparable, e.g. funcs, are never found and have to be removed through their handle.
+func (c *Client) RemovePlugin(p heimdall.Plugin) bool {
+	removed := c.plugins.Remove(p)
+	return c.client.RemovePlugin(p) && removed
+}
+
+// pluginHandles removes a plugin registered with both the circuit breaker and the http client
+type pluginHandles []heimdall.PluginHandle
+
+// Remove unregisters the plugin, returning false if it was already removed
+func (handles pluginHandles) Remove() bool {
+	removed := true
+	for _, handle := range handles {
+		removed = handle.Remove() && removed
+	}
+	return removed
+}
+
+// reportRejected reports a call rejected by the breaker as a call without any attempt
+func (c *Client) reportRejected(request *http.Request, err error) {
+	request = request.WithContext(internal.WithCallID(request.Context()))
+	plugins := c.plugins.Plugins()
+
+	for _, plugin := range plugins {
+		if p, ok := plugin.(heimdall.CallPlugin); ok {
+			internal.CallPlugin(c.pluginPanicHandler, plugin, "OnCallStart", func() { p.OnCallStart(request) })
+		}
+	}
+	for _, plugin := range plugins {
+		if p, ok := plugin.(heimdall.CircuitPlugin); ok {
+			internal.CallPlugin(c.pluginPanicHandler, plugin, "OnCircuitOpen", func() { p.OnCircuitOpen(request) })
+		}
+	}
+	for _, plugin := range plugins {
+		if p, ok := plugin.(heimdall.CallPlugin); ok {
+			internal.CallPlugin(c.pluginPanicHandler, plugin, "OnCallEnd", func() { p.OnCallEnd(request, nil, 0, err) })
+		}
+	}
+}
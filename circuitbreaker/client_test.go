@@ -0,0 +1,212 @@
+package circuitbreaker
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gojek/heimdall/v8"
+	"github.com/gojek/heimdall/v8/heimdalltest"
+	"github.com/gojek/heimdall/v8/httpclient"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type doerFunc func(*http.Request) (*http.Response, error)
+
+func (f doerFunc) Do(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func statusDoer(statusCode *int) heimdall.Doer {
+	return doerFunc(func(*http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: *statusCode, Body: http.NoBody}, nil
+	})
+}
+
+func TestCircuitBreakerClientOpensAndRecovers(t *testing.T) {
+	t.Parallel()
+
+	clock := heimdalltest.NewFakeClock(time.Unix(0, 0))
+	statusCode := http.StatusInternalServerError
+	calls := 0
+	client := NewClient(
+		WithClient(httpclient.NewClient(httpclient.WithHTTPClient(doerFunc(func(req *http.Request) (*http.Response, error) {
+			calls++
+			return statusDoer(&statusCode).Do(req)
+		})))),
+		WithClock(clock),
+		WithCountWindow(5),
+		WithMinimumCalls(5),
+		WithOpenDuration(10*time.Second),
+		WithHalfOpenProbes(1),
+	)
+
+	for range 5 {
+		response, err := client.Get("http://localhost", http.Header{})
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusInternalServerError, response.StatusCode)
+	}
+	assert.Equal(t, StateOpen, client.State())
+
+	_, err := client.Get("http://localhost", http.Header{})
+	require.ErrorIs(t, err, ErrCircuitOpen)
+	assert.Equal(t, 5, calls, "the rejected call shouldn't reach the server")
+
+	clock.Advance(10 * time.Second)
+	assert.Equal(t, StateHalfOpen, client.State())
+
+	statusCode = http.StatusOK
+	response, err := client.Get("http://localhost", http.Header{})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.Equal(t, StateClosed, client.State())
+}
+
+func TestCircuitBreakerClientWithTimeWindow(t *testing.T) {
+	t.Parallel()
+
+	clock := heimdalltest.NewFakeClock(time.Unix(0, 0))
+	statusCode := http.StatusServiceUnavailable
+	client := NewClient(
+		WithClient(httpclient.NewClient(httpclient.WithHTTPClient(statusDoer(&statusCode)))),
+		WithClock(clock),
+		WithTimeWindow(10*time.Second),
+		WithMinimumCalls(2),
+	)
+
+	_, err := client.Get("http://localhost", http.Header{})
+	require.NoError(t, err)
+	clock.Advance(10 * time.Second) // the first failure leaves the window
+	_, err = client.Get("http://localhost", http.Header{})
+	require.NoError(t, err)
+	assert.Equal(t, StateClosed, client.State())
+
+	_, err = client.Get("http://localhost", http.Header{})
+	require.NoError(t, err)
+	assert.Equal(t, StateOpen, client.State())
+}
+
+func TestCircuitBreakerClientCountsSlowCalls(t *testing.T) {
+	t.Parallel()
+
+	clock := heimdalltest.NewFakeClock(time.Unix(0, 0))
+	client := NewClient(
+		WithClient(httpclient.NewClient(httpclient.WithHTTPClient(doerFunc(func(*http.Request) (*http.Response, error) {
+			clock.Advance(time.Second)
+			return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody}, nil
+		})))),
+		WithClock(clock),
+		WithMinimumCalls(2),
+		WithSlowCallThreshold(500*time.Millisecond, 100),
+	)
+
+	for range 2 {
+		_, err := client.Get("http://localhost", http.Header{})
+		require.NoError(t, err)
+	}
+	assert.Equal(t, StateOpen, client.State())
+}
+
+func TestCircuitBreakerClientFailurePredicate(t *testing.T) {
+	t.Parallel()
+
+	statusCode := http.StatusTooManyRequests
+	client := NewClient(
+		WithClient(httpclient.NewClient(httpclient.WithHTTPClient(statusDoer(&statusCode)))),
+		WithMinimumCalls(1),
+		WithFailurePredicate(func(response *http.Response, err error) bool {
+			return err != nil || response.StatusCode == http.StatusTooManyRequests
+		}),
+	)
+
+	_, err := client.Get("http://localhost", http.Header{})
+	require.NoError(t, err)
+	assert.Equal(t, StateOpen, client.State())
+}
+
+func TestDefaultFailurePredicate(t *testing.T) {
+	t.Parallel()
+
+	assert.True(t, DefaultFailurePredicate(nil, errors.New("boom")))
+	assert.False(t, DefaultFailurePredicate(nil, context.Canceled))
+	assert.True(t, DefaultFailurePredicate(&http.Response{StatusCode: http.StatusBadGateway}, nil))
+	assert.False(t, DefaultFailurePredicate(&http.Response{StatusCode: http.StatusNotFound}, nil))
+}
+
+type rejectionPlugin struct {
+	events []string
+	err    error
+}
+
+func (p *rejectionPlugin) OnRequestStart(*http.Request) { p.events = append(p.events, "start") }
+func (p *rejectionPlugin) OnRequestEnd(*http.Request, *http.Response) {
+	p.events = append(p.events, "end")
+}
+func (p *rejectionPlugin) OnError(*http.Request, error) { p.events = append(p.events, "error") }
+func (p *rejectionPlugin) OnCallStart(*http.Request)    { p.events = append(p.events, "call start") }
+func (p *rejectionPlugin) OnCircuitOpen(*http.Request)  { p.events = append(p.events, "circuit open") }
+
+func (p *rejectionPlugin) OnCallEnd(_ *http.Request, _ *http.Response, _ int, err error) {
+	p.events = append(p.events, "call end")
+	p.err = err
+}
+
+func TestCircuitBreakerClientReportsRejectedCalls(t *testing.T) {
+	t.Parallel()
+
+	statusCode := http.StatusInternalServerError
+	client := NewClient(
+		WithClient(httpclient.NewClient(httpclient.WithHTTPClient(statusDoer(&statusCode)))),
+		WithMinimumCalls(1),
+	)
+	plugin := &rejectionPlugin{}
+	client.AddPlugin(plugin)
+
+	_, err := client.Get("http://localhost", http.Header{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"call start", "start", "end", "call end"}, plugin.events)
+
+	plugin.events = nil
+	body := &closeTracker{Reader: strings.NewReader("body")}
+	request, err := http.NewRequest(http.MethodPost, "http://localhost", body)
+	require.NoError(t, err)
+	_, err = client.Do(request)
+	require.ErrorIs(t, err, ErrCircuitOpen)
+	assert.Equal(t, []string{"call start", "circuit open", "call end"}, plugin.events)
+	assert.ErrorIs(t, plugin.err, ErrCircuitOpen)
+	assert.True(t, body.closed, "the body of a rejected call should be closed")
+
+	assert.True(t, client.RemovePlugin(plugin))
+	assert.False(t, client.RemovePlugin(plugin))
+}
+
+func TestCircuitBreakerClientRecordsPanickingCallsAsFailures(t *testing.T) {
+	t.Parallel()
+
+	client := NewClient(
+		WithClient(httpclient.NewClient(httpclient.WithHTTPClient(doerFunc(func(*http.Request) (*http.Response, error) {
+			panic("boom")
+		})))),
+		WithMinimumCalls(1),
+	)
+
+	assert.Panics(t, func() {
+		_, _ = client.Get("http://localhost", http.Header{})
+	})
+	assert.Equal(t, StateOpen, client.State())
+}
+
+type closeTracker struct {
+	io.Reader
+	closed bool
+}
+
+func (c *closeTracker) Close() error {
+	c.closed = true
+	return nil
+}
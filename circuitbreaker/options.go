@@ -0,0 +1,95 @@
+package circuitbreaker
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gojek/heimdall/v8"
+	"github.com/gojek/heimdall/v8/httpclient"
+)
+
+// Option represents the circuit breaker client options
+type Option func(*Client)
+
+// WithClient sets the http client guarded by the breaker, with its own timeouts, retries and middlewares
+func WithClient(client *httpclient.Client) Option {
+	return func(c *Client) {
+		c.client = client
+	}
+}
+
+// WithCountWindow sets the breaker to track the outcomes of the last given number of calls, 100 by default
+func WithCountWindow(size int) Option {
+	return func(c *Client) {
+		c.countWindowSize = max(size, 1)
+		c.timeWindow = 0
+	}
+}
+
+// WithTimeWindow sets the breaker to track the outcomes of the calls of the last given period
+func WithTimeWindow(period time.Duration) Option {
+	return func(c *Client) {
+		c.timeWindow = period
+	}
+}
+
+// WithMinimumCalls sets the number of calls in the window before the breaker can open, 10 by default
+func WithMinimumCalls(calls int) Option {
+	return func(c *Client) {
+		c.breaker.minimumCalls = max(calls, 1)
+	}
+}
+
+// WithFailureRateThreshold sets the percentage of failed calls in the window opening the breaker, 50 by default
+func WithFailureRateThreshold(percent float64) Option {
+	return func(c *Client) {
+		c.breaker.failureRateThreshold = percent
+	}
+}
+
+// WithSlowCallThreshold sets the breaker to also open when the given percentage of the calls in the window
+// took at least the given duration. Slow calls aren't tracked by default.
+func WithSlowCallThreshold(duration time.Duration, percent float64) Option {
+	return func(c *Client) {
+		c.breaker.slowCallDuration = duration
+		c.breaker.slowCallRate = percent
+	}
+}
+
+// WithOpenDuration sets how long the breaker stays open before letting probe calls through, 5 seconds by default
+func WithOpenDuration(duration time.Duration) Option {
+	return func(c *Client) {
+		c.breaker.openDuration = duration
+	}
+}
+
+// WithHalfOpenProbes sets the number of probe calls let through while half-open, 5 by default. The breaker
+// closes once they all completed, unless they exceed the failure or slow call rate thresholds.
+func WithHalfOpenProbes(probes int) Option {
+	return func(c *Client) {
+		c.breaker.halfOpenProbes = max(probes, 1)
+	}
+}
+
+// WithFailurePredicate sets the function telling whether a call failed, DefaultFailurePredicate by default
+func WithFailurePredicate(isFailure func(*http.Response, error) bool) Option {
+	return func(c *Client) {
+		c.isFailure = isFailure
+	}
+}
+
+// WithClock sets the clock of the breaker, timing calls and open durations, e.g. a fake clock in tests.
+// The wrapped http client keeps its own clock.
+func WithClock(clock heimdall.Clock) Option {
+	return func(c *Client) {
+		c.breaker.clock = clock
+	}
+}
+
+// WithPluginPanicHandler isolates the client from panicking plugins: panics in plugin hooks are recovered and
+// passed to the handler, and the request carries on. It also applies to the wrapped http client.
+func WithPluginPanicHandler(handler heimdall.PluginPanicHandler) Option {
+	return func(c *Client) {
+		c.pluginPanicHandler = handler
+	}
+}
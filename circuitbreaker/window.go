@@ -0,0 +1,127 @@
+package circuitbreaker
+
+import "time"
+
+// timeWindowBuckets is the number of buckets a time window is split into, outcomes expiring a bucket at a time
+const timeWindowBuckets = 10
+
+// counts are the outcomes of calls
+type counts struct {
+	calls    int
+	failures int
+	slow     int
+}
+
+func (c *counts) add(failure, slow bool) {
+	c.calls++
+	if failure {
+		c.failures++
+	}
+	if slow {
+		c.slow++
+	}
+}
+
+func (c *counts) remove(failure, slow bool) {
+	c.calls--
+	if failure {
+		c.failures--
+	}
+	if slow {
+		c.slow--
+	}
+}
+
+// window is a sliding window of the outcomes of calls
+type window interface {
+	record(now time.Time, failure, slow bool)
+	counts(now time.Time) counts
+	reset()
+}
+
+type outcome struct {
+	failure bool
+	slow    bool
+}
+
+// countWindow keeps the outcomes of the last calls
+type countWindow struct {
+	outcomes []outcome
+	next     int
+	total    counts
+}
+
+func newCountWindow(size int) *countWindow {
+	return &countWindow{outcomes: make([]outcome, 0, size)}
+}
+
+func (w *countWindow) record(_ time.Time, failure, slow bool) {
+	if len(w.outcomes) < cap(w.outcomes) {
+		w.outcomes = append(w.outcomes, outcome{failure: failure, slow: slow})
+	} else {
+		evicted := w.outcomes[w.next]
+		w.total.remove(evicted.failure, evicted.slow)
+		w.outcomes[w.next] = outcome{failure: failure, slow: slow}
+	}
+
+	w.next = (w.next + 1) % cap(w.outcomes)
+	w.total.add(failure, slow)
+}
+
+func (w *countWindow) counts(time.Time) counts {
+	return w.total
+}
+
+func (w *countWindow) reset() {
+	w.outcomes = w.outcomes[:0]
+	w.next = 0
+	w.total = counts{}
+}
+
+type bucket struct {
+	counts
+	epoch int64 // the index of the bucket since the zero time, telling whether it expired
+}
+
+// timeWindow keeps the outcomes of the calls of the last period, in buckets of a tenth of the period
+type timeWindow struct {
+	width   time.Duration
+	buckets [timeWindowBuckets]bucket
+}
+
+func newTimeWindow(period time.Duration) *timeWindow {
+	return &timeWindow{width: max(period/timeWindowBuckets, 1)}
+}
+
+func (w *timeWindow) record(now time.Time, failure, slow bool) {
+	epoch := w.epoch(now)
+	b := &w.buckets[epoch%timeWindowBuckets]
+	if b.epoch != epoch {
+		*b = bucket{epoch: epoch}
+	}
+
+	b.add(failure, slow)
+}
+
+func (w *timeWindow) counts(now time.Time) counts {
+	epoch := w.epoch(now)
+
+	var total counts
+	for _, b := range w.buckets {
+		if b.epoch > epoch-timeWindowBuckets && b.epoch <= epoch {
+			total.calls += b.calls
+			total.failures += b.failures
+			total.slow += b.slow
+		}
+	}
+
+	return total
+}
+
+func (w *timeWindow) reset() {
+	w.buckets = [timeWindowBuckets]bucket{}
+}
+
+func (w *timeWindow) epoch(now time.Time) int64 {
+	return now.UnixNano() / int64(w.width)
+}
@@ -0,0 +1,48 @@
+package circuitbreaker
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCountWindowKeepsTheLastCalls(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	window := newCountWindow(3)
+	window.record(now, true, true)
+	window.record(now, false, false)
+	window.record(now, true, false)
+	assert.Equal(t, counts{calls: 3, failures: 2, slow: 1}, window.counts(now))
+
+	window.record(now, false, false) // evicts the first call
+	assert.Equal(t, counts{calls: 3, failures: 1, slow: 0}, window.counts(now))
+
+	window.reset()
+	assert.Equal(t, counts{}, window.counts(now))
+	window.record(now, true, false)
+	assert.Equal(t, counts{calls: 1, failures: 1}, window.counts(now))
+}
+
+func TestTimeWindowKeepsTheCallsOfThePeriod(t *testing.T) {
+	t.Parallel()
+
+	start := time.Unix(1000, 0)
+	window := newTimeWindow(10 * time.Second)
+	window.record(start, true, false)
+	window.record(start.Add(5*time.Second), false, true)
+	assert.Equal(t, counts{calls: 2, failures: 1, slow: 1}, window.counts(start.Add(9*time.Second)))
+
+	// the first call expires, a bucket at a time
+	assert.Equal(t, counts{calls: 1, failures: 0, slow: 1}, window.counts(start.Add(10*time.Second)))
+
+	window.record(start.Add(12*time.Second), true, false) // reuses the bucket of the first call
+	assert.Equal(t, counts{calls: 2, failures: 1, slow: 1}, window.counts(start.Add(12*time.Second)))
+
+	assert.Equal(t, counts{}, window.counts(start.Add(time.Minute)))
+
+	window.reset()
+	assert.Equal(t, counts{}, window.counts(start.Add(12*time.Second)))
+}
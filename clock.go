@@ -0,0 +1,27 @@
+package heimdall
+
+import "time"
+
+// Clock tells the time and waits for durations to pass, letting tests replace real time.
+// Context deadlines and timeouts still run on real time.
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
+type systemClock struct{}
+
+// NewSystemClock returns a clock backed by the time package
+func NewSystemClock() Clock {
+	return systemClock{}
+}
+
+// Now returns the current local time
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+// After waits for the duration to elapse and then sends the current time on the returned channel
+func (systemClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
@@ -0,0 +1,31 @@
+package heimdall
+
+import "context"
+
+type attemptKey struct{}
+
+// ContextWithAttempt returns a copy of ctx carrying the number of the attempt being made, starting from 1
+func ContextWithAttempt(ctx context.Context, attempt int) context.Context {
+	return context.WithValue(ctx, attemptKey{}, attempt)
+}
+
+// AttemptFromContext returns the number of the attempt being made, which heimdall clients set on the context
+// of every attempt, e.g. for plugins to tell retries apart.
+func AttemptFromContext(ctx context.Context) (int, bool) {
+	attempt, ok := ctx.Value(attemptKey{}).(int)
+	return attempt, ok
+}
+
+type callIDKey struct{}
+
+// ContextWithCallID returns a copy of ctx carrying the identifier of the call being made, shared by all its attempts
+func ContextWithCallID(ctx context.Context, id uint64) context.Context {
+	return context.WithValue(ctx, callIDKey{}, id)
+}
+
+// CallIDFromContext returns the identifier of the call being made, which heimdall clients set on the context
+// of every attempt, e.g. for plugins to group the attempts of a call. Identifiers are unique within the process.
+func CallIDFromContext(ctx context.Context) (uint64, bool) {
+	id, ok := ctx.Value(callIDKey{}).(uint64)
+	return id, ok
+}
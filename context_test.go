@@ -0,0 +1,30 @@
+package heimdall
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAttemptFromContext(t *testing.T) {
+	t.Parallel()
+
+	_, ok := AttemptFromContext(context.Background())
+	assert.False(t, ok)
+
+	attempt, ok := AttemptFromContext(ContextWithAttempt(context.Background(), 3))
+	assert.True(t, ok)
+	assert.Equal(t, 3, attempt)
+}
+
+func TestCallIDFromContext(t *testing.T) {
+	t.Parallel()
+
+	_, ok := CallIDFromContext(context.Background())
+	assert.False(t, ok)
+
+	id, ok := CallIDFromContext(ContextWithCallID(context.Background(), 42))
+	assert.True(t, ok)
+	assert.Equal(t, uint64(42), id)
+}
@@ -0,0 +1,111 @@
+package heimdall
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ErrRetryableStatusExhausted is matched by errors.Is when a call ended with a response the retry policy would retry.
+var ErrRetryableStatusExhausted = errors.New("retries exhausted with retryable status code")
+
+// RetryableStatusError is returned along with the final response, by clients configured to do so,
+// when a call ends with a response the retry policy would retry. It matches ErrRetryableStatusExhausted with errors.Is.
+type RetryableStatusError struct {
+	StatusCode int // The status code of the final response
+	Attempts   int // The number of attempts made
+}
+
+func (e *RetryableStatusError) Error() string {
+	return fmt.Sprintf("%v: status %d after %d attempts", ErrRetryableStatusExhausted, e.StatusCode, e.Attempts)
+}
+
+func (e *RetryableStatusError) Is(target error) bool {
+	return target == ErrRetryableStatusExhausted
+}
+
+// RetryDeadlineError is returned when a retry is skipped because the backoff before it, plus the minimum time
+// needed for an attempt, doesn't fit in the time left before the context deadline.
+// It matches context.DeadlineExceeded with errors.Is.
+type RetryDeadlineError struct {
+	Retry     int           // The retry which was skipped, starting from 1
+	Backoff   time.Duration // The backoff which would have been slept before the retry
+	Remaining time.Duration // The time left before the context deadline
+}
+
+func (e *RetryDeadlineError) Error() string {
+	return fmt.Sprintf("retry %d skipped, backoff of %s exceeds the %s left: %v",
+		e.Retry, e.Backoff, max(e.Remaining, 0), context.DeadlineExceeded)
+}
+
+func (e *RetryDeadlineError) Unwrap() error {
+	return context.DeadlineExceeded
+}
+
+// Attempt describes a single attempt made during a call
+type Attempt struct {
+	Start       time.Time     // When the attempt was sent
+	Duration    time.Duration // How long the attempt took
+	Backoff     time.Duration // The backoff slept before the attempt, 0 for the first one
+	StatusCode  int           // The status code of the response, 0 if there was none
+	Err         error         // The error of the attempt, if any
+	RetryReason string        // Why the retry policy retried (or didn't retry) the attempt
+}
+
+func (a Attempt) String() string {
+	var sb strings.Builder
+	if a.Err != nil {
+		fmt.Fprintf(&sb, "%v", a.Err)
+	} else {
+		fmt.Fprintf(&sb, "status %d", a.StatusCode)
+	}
+	fmt.Fprintf(&sb, " in %s", a.Duration)
+	if a.Backoff > 0 {
+		fmt.Fprintf(&sb, " after %s backoff", a.Backoff)
+	}
+
+	return sb.String()
+}
+
+// RetriesExhaustedError is returned when a call made more than one attempt and failed,
+// recording the history of its attempts. It matches the errors of every attempt with errors.Is and errors.As.
+type RetriesExhaustedError struct {
+	Attempts   []Attempt // Every attempt made, in order
+	StatusCode int       // The status code of the final response, 0 if there was none
+	Err        error     // The error which ended the call other than those of the attempts (e.g. an interrupted backoff), if any
+}
+
+func (e *RetriesExhaustedError) Error() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "giving up after %d attempt", len(e.Attempts))
+	if len(e.Attempts) != 1 {
+		sb.WriteString("s")
+	}
+	if e.StatusCode != 0 {
+		fmt.Fprintf(&sb, " with status %d", e.StatusCode)
+	}
+	for i, attempt := range e.Attempts {
+		fmt.Fprintf(&sb, "; attempt %d: %s", i+1, attempt)
+	}
+	if e.Err != nil {
+		fmt.Fprintf(&sb, "; %v", e.Err)
+	}
+
+	return sb.String()
+}
+
+func (e *RetriesExhaustedError) Unwrap() []error {
+	var errs []error
+	for _, attempt := range e.Attempts {
+		if attempt.Err != nil {
+			errs = append(errs, attempt.Err)
+		}
+	}
+	if e.Err != nil {
+		errs = append(errs, e.Err)
+	}
+
+	return errs
+}
@@ -0,0 +1,82 @@
+package heimdall
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetryDeadlineError(t *testing.T) {
+	t.Parallel()
+
+	var err error = &RetryDeadlineError{Retry: 2, Backoff: 2 * time.Second, Remaining: 500 * time.Millisecond}
+
+	assert.Equal(t, "retry 2 skipped, backoff of 2s exceeds the 500ms left: context deadline exceeded", err.Error())
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	var deadlineErr *RetryDeadlineError
+	assert.True(t, errors.As(err, &deadlineErr))
+	assert.Equal(t, 2, deadlineErr.Retry)
+
+	err = &RetryDeadlineError{Retry: 1, Backoff: time.Second, Remaining: -time.Second}
+	assert.Equal(t, "retry 1 skipped, backoff of 1s exceeds the 0s left: context deadline exceeded", err.Error())
+}
+
+func TestRetriesExhaustedError(t *testing.T) {
+	t.Parallel()
+
+	err1 := errors.New("err1")
+	err2 := errors.New("err2")
+	start := time.Now()
+
+	var err error = &RetriesExhaustedError{
+		Attempts: []Attempt{
+			{Start: start, Duration: 10 * time.Millisecond, Err: err1, RetryReason: "request failed"},
+			{Start: start.Add(20 * time.Millisecond), Duration: 5 * time.Millisecond, Backoff: 10 * time.Millisecond, StatusCode: 502},
+		},
+		StatusCode: 502,
+		Err:        err2,
+	}
+
+	assert.Equal(t, "giving up after 2 attempts with status 502; attempt 1: err1 in 10ms; attempt 2: status 502 in 5ms after 10ms backoff; err2", err.Error())
+	assert.ErrorIs(t, err, err1)
+	assert.ErrorIs(t, err, err2)
+	assert.NotErrorIs(t, err, context.Canceled)
+
+	var exhausted *RetriesExhaustedError
+	assert.True(t, errors.As(err, &exhausted))
+	assert.Len(t, exhausted.Attempts, 2)
+	assert.Equal(t, []error{err1, err2}, exhausted.Unwrap())
+}
+
+func TestRetriesExhaustedErrorWithoutResponse(t *testing.T) {
+	t.Parallel()
+
+	err := &RetriesExhaustedError{
+		Attempts: []Attempt{{Duration: time.Second, Err: context.DeadlineExceeded}, {Duration: time.Second, Err: context.DeadlineExceeded}},
+	}
+
+	assert.Equal(t, "giving up after 2 attempts; attempt 1: context deadline exceeded in 1s; attempt 2: context deadline exceeded in 1s", err.Error())
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Len(t, err.Unwrap(), 2)
+}
+
+func TestRetryableStatusError(t *testing.T) {
+	t.Parallel()
+
+	var err error = &RetryableStatusError{StatusCode: 503, Attempts: 5}
+
+	assert.Equal(t, "retries exhausted with retryable status code: status 503 after 5 attempts", err.Error())
+	assert.ErrorIs(t, err, ErrRetryableStatusExhausted)
+	assert.ErrorIs(t, fmt.Errorf("wrapped: %w", err), ErrRetryableStatusExhausted)
+	assert.NotErrorIs(t, err, context.DeadlineExceeded)
+
+	var statusErr *RetryableStatusError
+	assert.True(t, errors.As(err, &statusErr))
+	assert.Equal(t, 503, statusErr.StatusCode)
+	assert.Equal(t, 5, statusErr.Attempts)
+}
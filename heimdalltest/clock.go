@@ -0,0 +1,95 @@
+package heimdalltest
+
+import (
+	"sync"
+	"time"
+
+	"github.com/gojek/heimdall/v8"
+)
+
+var _ heimdall.Clock = (*FakeClock)(nil)
+
+type fakeTimer struct {
+	deadline time.Time
+	fire     chan time.Time
+}
+
+// FakeClock is a clock which only moves when advanced, letting tests run retry schedules without real waiting.
+// It is safe for concurrent use.
+type FakeClock struct {
+	mu      sync.Mutex
+	changed *sync.Cond
+	now     time.Time
+	timers  []fakeTimer
+}
+
+// NewFakeClock returns a FakeClock set to the given time
+func NewFakeClock(now time.Time) *FakeClock {
+	c := &FakeClock{now: now}
+	c.changed = sync.NewCond(&c.mu)
+	return c
+}
+
+// Now returns the current time of the clock
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.now
+}
+
+// After returns a channel which receives the time of the clock once it has been advanced by d
+func (c *FakeClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	fire := make(chan time.Time, 1)
+	if d <= 0 {
+		fire <- c.now
+		return fire
+	}
+
+	c.timers = append(c.timers, fakeTimer{deadline: c.now.Add(d), fire: fire})
+	c.changed.Broadcast()
+	return fire
+}
+
+// Advance moves the clock forward by d, firing the timers which are due
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.now = c.now.Add(d)
+
+	pending := c.timers[:0]
+	for _, timer := range c.timers {
+		if timer.deadline.After(c.now) {
+			pending = append(pending, timer)
+			continue
+		}
+		timer.fire <- c.now
+	}
+	clear(c.timers[len(pending):])
+	c.timers = pending
+	c.changed.Broadcast()
+}
+
+// Timers returns the number of timers waiting for the clock to be advanced.
+// A timer stays pending after its waiter gave up, e.g. when a sleep was interrupted by a cancelled context.
+func (c *FakeClock) Timers() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return len(c.timers)
+}
+
+// BlockUntil blocks until at least n timers are waiting for the clock to be advanced, e.g. until a client
+// is sleeping before its next retry.
+func (c *FakeClock) BlockUntil(n int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for len(c.timers) < n {
+		c.changed.Wait()
+	}
+}
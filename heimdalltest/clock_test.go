@@ -0,0 +1,81 @@
+package heimdalltest_test
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gojek/heimdall/v8"
+	"github.com/gojek/heimdall/v8/heimdalltest"
+	"github.com/gojek/heimdall/v8/httpclient"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFakeClock(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2024, time.March, 1, 10, 0, 0, 0, time.UTC)
+	clock := heimdalltest.NewFakeClock(start)
+
+	short := clock.After(time.Second)
+	long := clock.After(time.Minute)
+	assert.Equal(t, 2, clock.Timers())
+
+	clock.Advance(time.Second)
+	assert.Equal(t, start.Add(time.Second), <-short)
+	assert.Equal(t, 1, clock.Timers())
+
+	select {
+	case <-long:
+		t.Fatal("timer fired before its deadline")
+	default:
+	}
+
+	clock.Advance(time.Hour)
+	assert.Equal(t, start.Add(time.Hour+time.Second), <-long)
+	assert.Equal(t, start.Add(time.Hour+time.Second), clock.Now())
+	assert.Equal(t, 0, clock.Timers())
+}
+
+func TestFakeClockFiresNonPositiveDurationsImmediately(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2024, time.March, 1, 10, 0, 0, 0, time.UTC)
+	clock := heimdalltest.NewFakeClock(start)
+
+	assert.Equal(t, start, <-clock.After(0))
+	assert.Equal(t, 0, clock.Timers())
+}
+
+func TestFakeClockDrivesRetrySchedule(t *testing.T) {
+	t.Parallel()
+
+	clock := heimdalltest.NewFakeClock(time.Date(2024, time.March, 1, 10, 0, 0, 0, time.UTC))
+	client := httpclient.NewClient(
+		httpclient.WithRetryCount(5),
+		httpclient.WithRetrier(heimdall.NewRetrier(heimdall.NewExponentialBackoff(time.Second, time.Minute, 2, 0))),
+		httpclient.WithClock(clock),
+		httpclient.WithHTTPClient(doerFunc(func(*http.Request) (*http.Response, error) {
+			return &http.Response{StatusCode: http.StatusServiceUnavailable, Body: http.NoBody}, nil
+		})),
+	)
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := client.Get("http://localhost", http.Header{})
+		done <- err
+	}()
+
+	for _, backoff := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second, 16 * time.Second} {
+		clock.BlockUntil(1)
+		clock.Advance(backoff)
+	}
+
+	select {
+	case err := <-done:
+		require.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("client still waiting after the schedule completed")
+	}
+}
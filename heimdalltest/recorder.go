@@ -0,0 +1,89 @@
+// Package heimdalltest provides helpers for testing code built on heimdall clients.
+package heimdalltest
+
+import (
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/gojek/heimdall/v8"
+)
+
+var _ heimdall.StatefulBackoff = (*RecordingBackoff)(nil)
+var _ heimdall.StatefulRetriable = (*RecordingRetrier)(nil)
+
+// recorder keeps the intervals returned by a backoff or a retrier
+type recorder struct {
+	mu        sync.Mutex
+	intervals []time.Duration
+}
+
+func (r *recorder) record(interval time.Duration) time.Duration {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.intervals = append(r.intervals, interval)
+	return interval
+}
+
+// Intervals returns the intervals returned so far, in order
+func (r *recorder) Intervals() []time.Duration {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return slices.Clone(r.intervals)
+}
+
+// Reset forgets the intervals returned so far
+func (r *recorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.intervals = nil
+}
+
+// RecordingBackoff is a backoff which records every interval returned by the backoff it wraps
+type RecordingBackoff struct {
+	recorder
+	backoff heimdall.Backoff
+}
+
+// NewRecordingBackoff returns a RecordingBackoff wrapping the given backoff
+func NewRecordingBackoff(backoff heimdall.Backoff) *RecordingBackoff {
+	return &RecordingBackoff{backoff: backoff}
+}
+
+// Next returns and records the next time of the wrapped backoff
+func (rb *RecordingBackoff) Next(retry int) time.Duration {
+	return rb.record(rb.backoff.Next(retry))
+}
+
+// NextState returns and records the next time of the wrapped backoff, passing the state to stateful backoffs
+func (rb *RecordingBackoff) NextState(state heimdall.BackoffState) time.Duration {
+	if sb, ok := rb.backoff.(heimdall.StatefulBackoff); ok {
+		return rb.record(sb.NextState(state))
+	}
+
+	return rb.Next(state.Retry)
+}
+
+// RecordingRetrier is a retrier which records every interval returned by the retrier it wraps
+type RecordingRetrier struct {
+	recorder
+	retrier heimdall.Retriable
+}
+
+// NewRecordingRetrier returns a RecordingRetrier wrapping the given retrier
+func NewRecordingRetrier(retrier heimdall.Retriable) *RecordingRetrier {
+	return &RecordingRetrier{retrier: retrier}
+}
+
+// NextInterval returns and records the next interval of the wrapped retrier
+func (rr *RecordingRetrier) NextInterval(retry int) time.Duration {
+	return rr.record(rr.retrier.NextInterval(retry))
+}
+
+// NextIntervalState returns and records the next interval of the wrapped retrier, passing the state to stateful retriers
+func (rr *RecordingRetrier) NextIntervalState(state heimdall.BackoffState) time.Duration {
+	return rr.record(heimdall.NextInterval(rr.retrier, state))
+}
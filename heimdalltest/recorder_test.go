@@ -0,0 +1,68 @@
+package heimdalltest_test
+
+import (
+	"math/rand/v2"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gojek/heimdall/v8"
+	"github.com/gojek/heimdall/v8/heimdalltest"
+	"github.com/gojek/heimdall/v8/httpclient"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type doerFunc func(*http.Request) (*http.Response, error)
+
+func (f doerFunc) Do(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestRecordingBackoff(t *testing.T) {
+	t.Parallel()
+
+	backoff := heimdalltest.NewRecordingBackoff(heimdall.NewExponentialBackoff(10*time.Millisecond, time.Second, 2, 0))
+
+	assert.Equal(t, 10*time.Millisecond, backoff.Next(0))
+	assert.Equal(t, 20*time.Millisecond, backoff.NextState(heimdall.BackoffState{Retry: 1}))
+	assert.Equal(t, []time.Duration{10 * time.Millisecond, 20 * time.Millisecond}, backoff.Intervals())
+
+	backoff.Reset()
+	assert.Empty(t, backoff.Intervals())
+}
+
+func TestRecordingBackoffPassesStateThrough(t *testing.T) {
+	t.Parallel()
+
+	backoff := heimdalltest.NewRecordingBackoff(heimdall.WithMaxElapsed(heimdall.NewConstantBackoff(10*time.Millisecond, 0), 15*time.Millisecond))
+
+	assert.Equal(t, heimdall.Stop, backoff.NextState(heimdall.BackoffState{Retry: 1, Elapsed: 10 * time.Millisecond}))
+	assert.Equal(t, []time.Duration{heimdall.Stop}, backoff.Intervals())
+}
+
+func TestRecordingRetrierGoldenSchedule(t *testing.T) {
+	t.Parallel()
+
+	newSchedule := func() []time.Duration {
+		retrier := heimdalltest.NewRecordingRetrier(heimdall.NewRetrier(heimdall.NewDecorrelatedJitterBackoff(
+			time.Millisecond, 20*time.Millisecond, heimdall.WithJitterSource(rand.NewPCG(1, 2)),
+		)))
+
+		client := httpclient.NewClient(
+			httpclient.WithRetryCount(4),
+			httpclient.WithRetrier(retrier),
+			httpclient.WithHTTPClient(doerFunc(func(*http.Request) (*http.Response, error) {
+				return &http.Response{StatusCode: http.StatusServiceUnavailable, Body: http.NoBody}, nil
+			})),
+		)
+
+		_, err := client.Get("http://localhost", http.Header{})
+		require.NoError(t, err)
+		return retrier.Intervals()
+	}
+
+	schedule := newSchedule()
+	assert.Len(t, schedule, 4)
+	assert.Equal(t, schedule, newSchedule(), "the same seed should give the same schedule")
+}
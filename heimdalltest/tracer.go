@@ -0,0 +1,116 @@
+package heimdalltest
+
+import (
+	"context"
+	"encoding/binary"
+	"maps"
+	"math/rand/v2"
+	"slices"
+	"sync"
+
+	"github.com/gojek/heimdall/v8/plugins"
+)
+
+var _ plugins.Tracer = (*RecordingTracer)(nil)
+
+type recordingSpanKey struct{}
+
+// RecordedSpan is a span started by a RecordingTracer
+type RecordedSpan struct {
+	Name        string
+	SpanContext plugins.SpanContext
+	Parent      plugins.SpanContext // zero for root spans
+	Attributes  map[string]any
+	Errors      []error
+	Ended       bool
+}
+
+// RecordingTracer is a tracer keeping the spans it starts in memory, to check the spans of calls in tests.
+// Spans are children of the span carried by the context they are started with, if any.
+// It is safe for concurrent use.
+type RecordingTracer struct {
+	mu    sync.Mutex
+	spans []*RecordedSpan
+}
+
+// NewRecordingTracer returns a new RecordingTracer
+func NewRecordingTracer() *RecordingTracer {
+	return &RecordingTracer{}
+}
+
+// Start starts a span, as a child of the span carried by ctx if any
+func (t *RecordingTracer) Start(ctx context.Context, name string) (context.Context, plugins.Span) {
+	span := &RecordedSpan{Name: name, Attributes: map[string]any{}}
+	span.SpanContext.Sampled = true
+	binary.BigEndian.PutUint64(span.SpanContext.SpanID[:], rand.Uint64())
+
+	if parent, ok := ctx.Value(recordingSpanKey{}).(*recordingSpan); ok {
+		span.Parent = parent.SpanContext()
+		span.SpanContext.TraceID = span.Parent.TraceID
+	} else {
+		binary.BigEndian.PutUint64(span.SpanContext.TraceID[:8], rand.Uint64())
+		binary.BigEndian.PutUint64(span.SpanContext.TraceID[8:], rand.Uint64())
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.spans = append(t.spans, span)
+	recording := &recordingSpan{tracer: t, span: span}
+	return context.WithValue(ctx, recordingSpanKey{}, recording), recording
+}
+
+// Spans returns a copy of the spans started so far, in the order they were started
+func (t *RecordingTracer) Spans() []RecordedSpan {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	spans := make([]RecordedSpan, len(t.spans))
+	for i, span := range t.spans {
+		spans[i] = *span
+		spans[i].Attributes = maps.Clone(span.Attributes)
+		spans[i].Errors = slices.Clone(span.Errors)
+	}
+	return spans
+}
+
+// Reset forgets the spans started so far
+func (t *RecordingTracer) Reset() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.spans = nil
+}
+
+type recordingSpan struct {
+	tracer *RecordingTracer
+	span   *RecordedSpan
+}
+
+func (s *recordingSpan) SpanContext() plugins.SpanContext {
+	s.tracer.mu.Lock()
+	defer s.tracer.mu.Unlock()
+
+	return s.span.SpanContext
+}
+
+func (s *recordingSpan) SetAttribute(key string, value any) {
+	s.tracer.mu.Lock()
+	defer s.tracer.mu.Unlock()
+
+	s.span.Attributes[key] = value
+}
+
+func (s *recordingSpan) RecordError(err error) {
+	s.tracer.mu.Lock()
+	defer s.tracer.mu.Unlock()
+
+	s.span.Errors = append(s.span.Errors, err)
+}
+
+func (s *recordingSpan) End() {
+	s.tracer.mu.Lock()
+	defer s.tracer.mu.Unlock()
+
+	s.span.Ended = true
+}
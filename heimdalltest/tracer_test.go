@@ -0,0 +1,39 @@
+package heimdalltest_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/gojek/heimdall/v8/heimdalltest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecordingTracer(t *testing.T) {
+	t.Parallel()
+
+	tracer := heimdalltest.NewRecordingTracer()
+	ctx, parent := tracer.Start(context.Background(), "parent")
+	_, child := tracer.Start(ctx, "child")
+	child.SetAttribute("key", "value")
+	child.RecordError(errors.New("boom"))
+	child.End()
+
+	spans := tracer.Spans()
+	require.Len(t, spans, 2)
+	assert.Equal(t, "parent", spans[0].Name)
+	assert.True(t, spans[0].SpanContext.IsValid())
+	assert.False(t, spans[0].Parent.IsValid())
+	assert.False(t, spans[0].Ended)
+
+	assert.Equal(t, parent.SpanContext(), spans[1].Parent)
+	assert.Equal(t, parent.SpanContext().TraceID, spans[1].SpanContext.TraceID)
+	assert.NotEqual(t, parent.SpanContext().SpanID, spans[1].SpanContext.SpanID)
+	assert.Equal(t, map[string]any{"key": "value"}, spans[1].Attributes)
+	assert.Len(t, spans[1].Errors, 1)
+	assert.True(t, spans[1].Ended)
+
+	tracer.Reset()
+	assert.Empty(t, tracer.Spans())
+}
@@ -5,7 +5,6 @@ import (
 	"fmt"
 	"io"
 	"net/http"
-	"slices"
 	"time"
 
 	"github.com/gojek/heimdall/v8"
@@ -15,13 +14,37 @@ import (
 // Client is the http client implementation
 type Client struct {
 	client  heimdall.Doer
-	plugins []heimdall.Plugin
+	plugins internal.PluginRegistry
 	timeout *time.Duration
+	clock   heimdall.Clock
+
+	pluginPanicHandler heimdall.PluginPanicHandler
+
+	middlewares []heimdall.Middleware
+	attempt     heimdall.Doer
+
+	perAttemptTimeout time.Duration
+	totalTimeout      time.Duration
+	minAttemptTime    time.Duration
 
 	retrier          heimdall.Retriable
 	retryCount       int
 	retryableCodes   []int
+	retryPolicy      heimdall.RetryPolicy
 	retryErrorBudget *internal.ErrorBudget
+
+	honorRetryAfter bool
+	maxRetryAfter   time.Duration
+
+	retryableStatusError bool
+
+	idempotentRetries bool
+	idempotencyKey    func() string
+
+	maxHedges       int
+	hedgeAfter      time.Duration
+	hedgePercentile float64
+	hedgeLatencies  *internal.LatencyTracker
 }
 
 const (
@@ -37,7 +60,9 @@ func NewClient(opts ...Option) *Client {
 		client:     &http.Client{Timeout: defaultHTTPTimeout},
 		retryCount: defaultRetryCount,
 		retrier:    heimdall.NewNoRetrier(),
+		clock:      heimdall.NewSystemClock(),
 	}
+	client.chainMiddlewares()
 
 	for _, opt := range opts {
 		opt(&client)
@@ -45,12 +70,32 @@ func NewClient(opts ...Option) *Client {
 
 	client.updateHTTPTimeout()
 
+	if client.retryPolicy == nil {
+		client.retryPolicy = heimdall.DefaultRetryPolicy(client.retryableCodes...)
+	}
+	if client.idempotentRetries {
+		client.retryPolicy = heimdall.IdempotentRetryPolicy(client.retryPolicy)
+	}
+
 	return &client
 }
 
 // AddPlugin Adds plugin to client
 func (c *Client) AddPlugin(p heimdall.Plugin) {
-	c.plugins = append(c.plugins, p)
+	c.plugins.Add(p, 0)
+}
+
+// RegisterPlugin adds a plugin to the client with the given priority, plugins with a higher priority being
+// called first. Plugins added with AddPlugin have a priority of 0. The returned handle removes the plugin.
+// Plugins can be registered and removed while the client is in use.
+func (c *Client) RegisterPlugin(p heimdall.Plugin, priority int) heimdall.PluginHandle {
+	return c.plugins.Add(p, priority)
+}
+
+// RemovePlugin removes a plugin from the client, returning false if it wasn't added.
+// Plugins whose type isn't comparable, e.g. funcs, are never found and have to be removed through their handle.
+func (c *Client) RemovePlugin(p heimdall.Plugin) bool {
+	return c.plugins.Remove(p)
 }
 
 // Get makes a HTTP GET request to provided URL
@@ -119,7 +164,7 @@ func (c *Client) Delete(url string, headers http.Header) (*http.Response, error)
 }
 
 // Do makes an HTTP request with the native `http.Do` interface
-func (c *Client) Do(request *http.Request) (*http.Response, error) {
+func (c *Client) Do(request *http.Request) (response *http.Response, err error) {
 	if origReqBody := request.Body; origReqBody != nil {
 		defer func() {
 			// close the original request body as internal.SetRequestGetBody wraps body with noop closer.
@@ -127,10 +172,40 @@ func (c *Client) Do(request *http.Request) (*http.Response, error) {
 		}()
 	}
 
+	if c.totalTimeout > 0 {
+		ctx, cancel := context.WithTimeout(request.Context(), c.totalTimeout)
+		request = request.WithContext(ctx)
+		defer func() {
+			if response != nil && response.Body != nil {
+				response.Body = internal.CancelOnClose(response.Body, cancel) // the caller still has to read the body
+				return
+			}
+			cancel()
+		}()
+	}
+
+	request = request.WithContext(internal.WithCallID(request.Context())) // shared by every attempt of the call
+
+	if c.idempotencyKey != nil && !heimdall.IsIdempotentMethod(request.Method) &&
+		request.Header.Get(heimdall.IdempotencyKeyHeader) == "" {
+		// set on a copy so that the caller's headers are left untouched, and every attempt carries the same key
+		request = request.WithContext(request.Context())
+		request.Header = request.Header.Clone()
+		if request.Header == nil {
+			request.Header = http.Header{}
+		}
+		request.Header.Set(heimdall.IdempotencyKeyHeader, c.idempotencyKey())
+	}
+
+	var attempts []heimdall.Attempt
+	c.reportCallStart(request)
+	defer func(call *http.Request) {
+		c.reportCallEnd(call, response, len(attempts), err)
+	}(request)
+
 	var reqGetBody internal.RequestGetBody
-	var err error
-	// Only SetRequestGetBody if retry is enabled to avoid unnecessary overhead for non-retry requests
-	if c.retryCount > 0 {
+	// Only SetRequestGetBody if retry or hedging is enabled to avoid unnecessary overhead for single attempt requests
+	if c.retryCount > 0 || c.maxHedges > 0 {
 		if err = internal.SetRequestGetBody(request); err != nil {
 			return nil, err
 		}
@@ -138,84 +213,281 @@ func (c *Client) Do(request *http.Request) (*http.Response, error) {
 		reqGetBody = request.GetBody
 	}
 
-	var errs []error
-	var response *http.Response
+	var backoff, elapsed time.Duration
+	var stopErr error
+	var retry bool
 
 	for i := 0; i <= c.retryCount; i++ {
-		if response != nil {
-			_, _ = io.Copy(io.Discard, response.Body)
-			_ = response.Body.Close()
-		}
 		if i > 0 {
-			if err := internal.SleepInterruptible(request.Context(), c.retrier.NextInterval(i-1)); err != nil {
-				errs = append(errs, err)
+			backoff = c.nextInterval(heimdall.BackoffState{Retry: i - 1, Previous: backoff, Elapsed: elapsed}, response)
+			if backoff == heimdall.Stop {
+				break
+			}
+			elapsed += backoff
+
+			if err := c.checkRetryDeadline(request.Context(), i, backoff); err != nil {
+				stopErr = err
+				c.reportError(request, err)
+				// no point of sleeping if the retry can't complete before the deadline, the last response is returned
+				break
+			}
+			internal.DiscardResponse(response)
+
+			c.reportRetry(request, i+1, backoff, attempts[len(attempts)-1])
+
+			if err := internal.SleepInterruptible(request.Context(), c.clock.After, backoff); err != nil {
+				stopErr = err
 				c.reportError(request, err)
 				// no point of retrying after context has been cancelled
 				break
 			}
 
-			request, err = internal.CloneRequest(request, reqGetBody) // Clone the request to reset the body for retry
+			clone, err := internal.CloneRequest(request, reqGetBody) // Clone the request to reset the body for retry
 			if err != nil {
-				errs = append(errs, err)
+				stopErr = err
 				c.reportError(request, err)
 				break
 			}
+			request = clone
 		}
 
-		c.reportRequestStart(request)
-		var err error
-		response, err = c.client.Do(request)
+		if _, ok := heimdall.AttemptFromContext(request.Context()); !ok || i > 0 {
+			// the first attempt keeps the number set by an outer client, e.g. the hystrix client
+			request = request.WithContext(heimdall.ContextWithAttempt(request.Context(), i+1))
+		}
+
+		attempt := heimdall.Attempt{Start: c.clock.Now(), Backoff: backoff}
+		if c.maxHedges > 0 && canHedge(request) {
+			response, err = c.hedgedDo(request, reqGetBody)
+		} else {
+			response, err = c.send(request)
+		}
+		attempt.Duration = c.clock.Now().Sub(attempt.Start)
+		attempt.Err = err
+		if response != nil {
+			attempt.StatusCode = response.StatusCode
+		}
+
+		retry, attempt.RetryReason = c.retryPolicy(request.Context(), request, response, err)
+		attempts = append(attempts, attempt)
 
 		if err != nil {
-			errs = append(errs, err)
-			c.reportError(request, err)
-			if c.skipRetry(request.Context()) {
+			if !retry || c.skipRetry(request, i < c.retryCount) {
 				break
 			}
 			continue
 		}
-		c.reportRequestEnd(request, response)
 
-		if _, ok := slices.BinarySearch(c.retryableCodes, response.StatusCode); ok ||
-			response.StatusCode >= http.StatusInternalServerError {
-			if c.skipRetry(request.Context()) {
+		if retry {
+			if c.skipRetry(request, i < c.retryCount) {
 				break
 			}
 			continue
 		}
 
-		errs = nil // Clear errors if any iteration succeeds
 		_ = c.retryErrorBudget.Success()
-		break
+		return response, nil
+	}
+
+	var statusErr error
+	if stopErr == nil && err == nil && retry {
+		statusErr = &heimdall.RetryableStatusError{StatusCode: response.StatusCode, Attempts: len(attempts)}
+	}
+
+	if c.retryableStatusError && statusErr != nil {
+		if exhaustedError(attempts, nil, response) == nil {
+			c.reportGiveUp(request, len(attempts), statusErr)
+			return response, statusErr
+		}
+		stopErr = statusErr
+	}
+
+	err = exhaustedError(attempts, stopErr, response)
+	if err != nil {
+		c.reportGiveUp(request, len(attempts), err)
+	} else {
+		c.reportGiveUp(request, len(attempts), statusErr)
 	}
 
-	return response, internal.BuildMultiError(errs)
+	return response, err
 }
 
-func (c *Client) skipRetry(ctx context.Context) bool {
-	if internal.IsCtxDone(ctx) {
+// exhaustedError returns the error of a failed call. A call with a single attempt fails with the error of that
+// attempt, otherwise a heimdall.RetriesExhaustedError records its attempts. A call whose attempts all failed
+// with a retryable response rather than an error doesn't fail.
+func exhaustedError(attempts []heimdall.Attempt, stopErr error, response *http.Response) error {
+	var errs []error
+	for _, attempt := range attempts {
+		if attempt.Err != nil {
+			errs = append(errs, attempt.Err)
+		}
+	}
+	if stopErr != nil {
+		errs = append(errs, stopErr)
+	}
+
+	switch {
+	case len(errs) == 0:
+		return nil
+	case len(attempts) <= 1 && len(errs) == 1:
+		return errs[0]
+	}
+
+	exhausted := &heimdall.RetriesExhaustedError{Attempts: attempts, Err: stopErr}
+	if response != nil {
+		exhausted.StatusCode = response.StatusCode
+	}
+
+	return exhausted
+}
+
+// send makes a single attempt through the middlewares
+func (c *Client) send(request *http.Request) (*http.Response, error) {
+	// middlewares and plugins may change the headers, which must not leak into the caller's map or the next attempt
+	request = request.WithContext(request.Context())
+	request.Header = request.Header.Clone()
+
+	if c.perAttemptTimeout <= 0 {
+		return c.attempt.Do(request)
+	}
+
+	ctx, cancel := context.WithTimeout(request.Context(), c.perAttemptTimeout)
+	response, err := c.attempt.Do(request.WithContext(ctx))
+	if err != nil || response.Body == nil {
+		cancel()
+		return response, err
+	}
+
+	response.Body = internal.CancelOnClose(response.Body, cancel) // the deadline also covers reading the body
+	return response, nil
+}
+
+// chainMiddlewares builds the Doer making each attempt: the middlewares wrap the plugins, which in turn
+// wrap the underlying Doer. Plugins thus observe requests as they are sent.
+func (c *Client) chainMiddlewares() {
+	c.attempt = heimdall.Chain(c.middlewares...)(heimdall.DoerFunc(c.report))
+}
+
+// report calls the underlying Doer, reporting the attempt to plugins
+func (c *Client) report(request *http.Request) (*http.Response, error) {
+	plugins := c.plugins.Plugins()                   // the plugins told about the start of the attempt are told about its end
+	request = request.WithContext(request.Context()) // plugins changing the request only change this attempt
+	c.reportRequestStart(plugins, request)
+	response, err := c.client.Do(request)
+	if err != nil {
+		c.reportErrorTo(plugins, request, err)
+		return response, err
+	}
+	c.reportRequestEnd(plugins, request, response)
+
+	return response, nil
+}
+
+// nextInterval returns the backoff before the given retry, preferring the wait requested by the server
+// through Retry-After when enabled.
+func (c *Client) nextInterval(state heimdall.BackoffState, response *http.Response) time.Duration {
+	if c.honorRetryAfter {
+		if wait, ok := internal.RetryAfter(response, c.clock.Now()); ok {
+			if c.maxRetryAfter > 0 {
+				return min(wait, c.maxRetryAfter)
+			}
+			return wait
+		}
+	}
+
+	return heimdall.NextInterval(c.retrier, state)
+}
+
+// checkRetryDeadline returns an error if the backoff before the given retry along with the minimum attempt time
+// doesn't fit in the time left before the context deadline.
+func (c *Client) checkRetryDeadline(ctx context.Context, retry int, interval time.Duration) error {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return nil
+	}
+
+	if remaining := time.Until(deadline); remaining < interval+c.minAttemptTime {
+		return &heimdall.RetryDeadlineError{Retry: retry, Backoff: interval, Remaining: remaining}
+	}
+
+	return nil
+}
+
+func (c *Client) skipRetry(request *http.Request, retriesLeft bool) bool {
+	if internal.IsCtxDone(request.Context()) {
 		_ = c.retryErrorBudget.Success()
 		return true
 	}
 
-	return c.retryErrorBudget.Failure()
+	if c.retryErrorBudget.Failure() {
+		if retriesLeft {
+			c.reportBudgetExhausted(request)
+		}
+		return true
+	}
+
+	return false
 }
 
-func (c *Client) reportRequestStart(request *http.Request) {
-	for _, plugin := range c.plugins {
-		plugin.OnRequestStart(request)
+func (c *Client) reportRequestStart(plugins []heimdall.Plugin, request *http.Request) {
+	for _, plugin := range plugins {
+		internal.CallPlugin(c.pluginPanicHandler, plugin, "OnRequestStart", func() { plugin.OnRequestStart(request) })
 	}
 }
 
 func (c *Client) reportError(request *http.Request, err error) {
-	for _, plugin := range c.plugins {
-		plugin.OnError(request, err)
+	c.reportErrorTo(c.plugins.Plugins(), request, err)
+}
+
+func (c *Client) reportErrorTo(plugins []heimdall.Plugin, request *http.Request, err error) {
+	for _, plugin := range plugins {
+		internal.CallPlugin(c.pluginPanicHandler, plugin, "OnError", func() { plugin.OnError(request, err) })
+	}
+}
+
+func (c *Client) reportRequestEnd(plugins []heimdall.Plugin, request *http.Request, response *http.Response) {
+	for _, plugin := range plugins {
+		internal.CallPlugin(c.pluginPanicHandler, plugin, "OnRequestEnd", func() { plugin.OnRequestEnd(request, response) })
+	}
+}
+
+func (c *Client) reportCallStart(request *http.Request) {
+	for _, plugin := range c.plugins.Plugins() {
+		if p, ok := plugin.(heimdall.CallPlugin); ok {
+			internal.CallPlugin(c.pluginPanicHandler, plugin, "OnCallStart", func() { p.OnCallStart(request) })
+		}
+	}
+}
+
+func (c *Client) reportCallEnd(request *http.Request, response *http.Response, attempts int, err error) {
+	for _, plugin := range c.plugins.Plugins() {
+		if p, ok := plugin.(heimdall.CallPlugin); ok {
+			internal.CallPlugin(c.pluginPanicHandler, plugin, "OnCallEnd", func() { p.OnCallEnd(request, response, attempts, err) })
+		}
+	}
+}
+
+func (c *Client) reportRetry(request *http.Request, attempt int, wait time.Duration, cause heimdall.Attempt) {
+	for _, plugin := range c.plugins.Plugins() {
+		if p, ok := plugin.(heimdall.RetryPlugin); ok {
+			internal.CallPlugin(c.pluginPanicHandler, plugin, "OnRetry", func() { p.OnRetry(request, attempt, wait, cause) })
+		}
 	}
 }
 
-func (c *Client) reportRequestEnd(request *http.Request, response *http.Response) {
-	for _, plugin := range c.plugins {
-		plugin.OnRequestEnd(request, response)
+func (c *Client) reportGiveUp(request *http.Request, attempts int, err error) {
+	for _, plugin := range c.plugins.Plugins() {
+		if p, ok := plugin.(heimdall.GiveUpPlugin); ok {
+			internal.CallPlugin(c.pluginPanicHandler, plugin, "OnGiveUp", func() { p.OnGiveUp(request, attempts, err) })
+		}
+	}
+}
+
+func (c *Client) reportBudgetExhausted(request *http.Request) {
+	for _, plugin := range c.plugins.Plugins() {
+		if p, ok := plugin.(heimdall.BudgetPlugin); ok {
+			internal.CallPlugin(c.pluginPanicHandler, plugin, "OnBudgetExhausted", func() { p.OnBudgetExhausted(request) })
+		}
 	}
 }
 
@@ -4,11 +4,13 @@ import (
 	"bytes"
 	"context"
 	"errors"
+	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
 	"strings"
+	"sync"
 	"sync/atomic"
 	"testing"
 	"time"
@@ -815,3 +817,1172 @@ func TestHTTPClientRetriesOnSpecificStatusCodes(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, "{ \"response\": \"ok\" }", string(body))
 }
+
+func TestHTTPClientHonorsRetryAfter(t *testing.T) {
+	t.Parallel()
+
+	client := NewClient(
+		WithHTTPTimeout(10*time.Millisecond),
+		WithRetryCount(1),
+		WithRetryableStatusCodes(http.StatusTooManyRequests),
+		WithRetrier(heimdall.NewRetrierFunc(func(retry int) time.Duration {
+			assert.Fail(t, "retrier should not be consulted when Retry-After is present")
+			return 0
+		})),
+		WithRetryAfter(30*time.Millisecond),
+	)
+
+	var calls []time.Time
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls = append(calls, time.Now())
+		if len(calls) == 1 {
+			w.Header().Set("Retry-After", "120") // capped by WithRetryAfter
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	response, err := client.Get(server.URL, http.Header{})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	require.Len(t, calls, 2)
+	assert.GreaterOrEqual(t, calls[1].Sub(calls[0]), 30*time.Millisecond)
+	assert.Less(t, calls[1].Sub(calls[0]), time.Second)
+}
+
+func TestHTTPClientRetryAfterWithoutCap(t *testing.T) {
+	t.Parallel()
+
+	response := &http.Response{
+		StatusCode: http.StatusServiceUnavailable,
+		Header:     http.Header{"Retry-After": {"120"}},
+	}
+
+	uncapped := NewClient(WithRetryAfter(0))
+	assert.Equal(t, 120*time.Second, uncapped.nextInterval(heimdall.BackoffState{}, response))
+
+	capped := NewClient(WithRetryAfter(time.Minute))
+	assert.Equal(t, time.Minute, capped.nextInterval(heimdall.BackoffState{}, response))
+}
+
+func TestHTTPClientOversizedRetryAfter(t *testing.T) {
+	t.Parallel()
+
+	response := &http.Response{
+		StatusCode: http.StatusTooManyRequests,
+		Header:     http.Header{"Retry-After": {"10000000000"}},
+	}
+
+	uncapped := NewClient(WithRetryAfter(0))
+	assert.Greater(t, uncapped.nextInterval(heimdall.BackoffState{}, response), 100*365*24*time.Hour, "the wait should saturate rather than overflow")
+
+	capped := NewClient(WithRetryAfter(time.Minute))
+	assert.Equal(t, time.Minute, capped.nextInterval(heimdall.BackoffState{}, response))
+}
+
+func TestHTTPClientRetryAfterBeyondDeadlineGivesUp(t *testing.T) {
+	t.Parallel()
+
+	client := NewClient(
+		WithHTTPTimeout(10*time.Millisecond),
+		WithRetryCount(3),
+		WithRetryAfter(time.Minute),
+	)
+
+	count := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		count++
+		w.Header().Set("Retry-After", "30")
+		w.WriteHeader(http.StatusServiceUnavailable)
+		_, _ = w.Write([]byte(`{ "response": "slow down" }`))
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+	require.NoError(t, err)
+
+	start := time.Now()
+	response, err := client.Do(req)
+	assert.Less(t, time.Since(start), 500*time.Millisecond, "should not wait for a Retry-After beyond the deadline")
+	assert.Equal(t, 1, count)
+	require.NotNil(t, response)
+	assert.Equal(t, http.StatusServiceUnavailable, response.StatusCode)
+	assert.Equal(t, "30", response.Header.Get("Retry-After"))
+	assert.Equal(t, `{ "response": "slow down" }`, respBody(t, response))
+
+	var deadlineErr *heimdall.RetryDeadlineError
+	require.ErrorAs(t, err, &deadlineErr)
+	assert.Equal(t, 1, deadlineErr.Retry)
+	assert.Equal(t, 30*time.Second, deadlineErr.Backoff)
+}
+
+func TestHTTPClientOversizedRetryAfterDoesNotHammer(t *testing.T) {
+	t.Parallel()
+
+	client := NewClient(
+		WithHTTPTimeout(10*time.Millisecond),
+		WithRetryCount(3),
+		WithRetryAfter(0),
+	)
+
+	count := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		count++
+		w.Header().Set("Retry-After", "10000000000")
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+	require.NoError(t, err)
+
+	response, err := client.Do(req)
+	assert.Equal(t, 1, count, "an oversized Retry-After should not be retried right away")
+	require.NotNil(t, response)
+	assert.Equal(t, http.StatusServiceUnavailable, response.StatusCode)
+
+	var deadlineErr *heimdall.RetryDeadlineError
+	require.ErrorAs(t, err, &deadlineErr)
+}
+
+func TestHTTPClientSkipsBackoffBeyondDeadline(t *testing.T) {
+	t.Parallel()
+
+	client := NewClient(
+		WithHTTPTimeout(100*time.Millisecond),
+		WithRetryCount(3),
+		WithRetrier(heimdall.NewRetrier(heimdall.NewConstantBackoff(100*time.Millisecond, 0))),
+		WithMinAttemptTime(300*time.Millisecond),
+	)
+
+	count := atomic.Int32{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		count.Add(1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 350*time.Millisecond)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+	require.NoError(t, err)
+
+	start := time.Now()
+	_, err = client.Do(req)
+	assert.Less(t, time.Since(start), 100*time.Millisecond, "should fail fast instead of sleeping")
+	assert.Equal(t, int32(1), count.Load())
+
+	var deadlineErr *heimdall.RetryDeadlineError
+	require.ErrorAs(t, err, &deadlineErr)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, 1, deadlineErr.Retry)
+	assert.Equal(t, 100*time.Millisecond, deadlineErr.Backoff)
+	assert.Less(t, deadlineErr.Remaining, 400*time.Millisecond)
+}
+
+func TestHTTPClientRetriesWhenBackoffFitsBeforeDeadline(t *testing.T) {
+	t.Parallel()
+
+	client := NewClient(
+		WithHTTPTimeout(100*time.Millisecond),
+		WithRetryCount(1),
+		WithRetrier(heimdall.NewRetrier(heimdall.NewConstantBackoff(time.Millisecond, 0))),
+		WithMinAttemptTime(10*time.Millisecond),
+	)
+
+	count := atomic.Int32{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		count.Add(1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+	require.NoError(t, err)
+
+	response, err := client.Do(req)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusInternalServerError, response.StatusCode)
+	assert.Equal(t, int32(2), count.Load())
+}
+
+func TestHTTPClientRetryPolicy(t *testing.T) {
+	t.Parallel()
+
+	client := NewClient(
+		WithHTTPTimeout(10*time.Millisecond),
+		WithRetryCount(3),
+		WithRetryPolicy(heimdall.NeverRetryStatusCodes(
+			heimdall.AnyRetryPolicy(heimdall.DefaultRetryPolicy(), heimdall.RetryOnStatusCodes(http.StatusConflict)),
+			http.StatusNotImplemented,
+		)),
+	)
+
+	var statusCodes []int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(statusCodes[0])
+		statusCodes = statusCodes[1:]
+	}))
+	defer server.Close()
+
+	statusCodes = []int{http.StatusConflict, http.StatusServiceUnavailable, http.StatusNotImplemented, http.StatusOK}
+	response, err := client.Get(server.URL, http.Header{})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNotImplemented, response.StatusCode)
+	assert.Equal(t, []int{http.StatusOK}, statusCodes, "should stop retrying on 501")
+}
+
+func TestHTTPClientRetryPolicyStopsOnNonRetryableError(t *testing.T) {
+	t.Parallel()
+
+	count := 0
+	client := NewClient(
+		WithRetryCount(3),
+		WithHTTPClient(heimdallDoerFunc(func(*http.Request) (*http.Response, error) {
+			count++
+			return nil, errors.New("unsupported protocol scheme")
+		})),
+		WithRetryPolicy(heimdall.RetryOnConnectionErrors()),
+	)
+
+	response, err := client.Get("does_not_exist", http.Header{})
+	require.Error(t, err)
+	assert.Nil(t, response)
+	assert.Equal(t, 1, count)
+}
+
+type heimdallDoerFunc func(*http.Request) (*http.Response, error)
+
+func (f heimdallDoerFunc) Do(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestHTTPClientIdempotentRetries(t *testing.T) {
+	t.Parallel()
+
+	client := NewClient(
+		WithHTTPTimeout(time.Second),
+		WithRetryCount(3),
+		WithIdempotentRetries(),
+	)
+
+	count := atomic.Int32{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		count.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	response, err := client.Post(server.URL, strings.NewReader("payment"), http.Header{})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusServiceUnavailable, response.StatusCode)
+	assert.Equal(t, int32(1), count.Load(), "POST without Idempotency-Key should not be retried")
+
+	count.Store(0)
+	response, err = client.Post(server.URL, strings.NewReader("payment"), http.Header{heimdall.IdempotencyKeyHeader: {"key"}})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusServiceUnavailable, response.StatusCode)
+	assert.Equal(t, int32(4), count.Load())
+
+	count.Store(0)
+	response, err = client.Get(server.URL, http.Header{})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusServiceUnavailable, response.StatusCode)
+	assert.Equal(t, int32(4), count.Load())
+}
+
+func TestHTTPClientIdempotencyKeyReusedAcrossAttempts(t *testing.T) {
+	t.Parallel()
+
+	generated := 0
+	client := NewClient(
+		WithHTTPTimeout(time.Second),
+		WithRetryCount(2),
+		WithIdempotentRetries(),
+		WithIdempotencyKey(func() string {
+			generated++
+			return "generated-key"
+		}),
+	)
+
+	var keys []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		keys = append(keys, r.Header.Get(heimdall.IdempotencyKeyHeader))
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	headers := http.Header{}
+	_, err := client.Post(server.URL, strings.NewReader("payment"), headers)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"generated-key", "generated-key", "generated-key"}, keys)
+	assert.Equal(t, 1, generated)
+	assert.Empty(t, headers.Get(heimdall.IdempotencyKeyHeader), "caller headers should be left untouched")
+
+	keys = nil
+	_, err = client.Post(server.URL, strings.NewReader("payment"), http.Header{heimdall.IdempotencyKeyHeader: {"caller-key"}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"caller-key", "caller-key", "caller-key"}, keys)
+	assert.Equal(t, 1, generated)
+
+	keys = nil
+	_, err = client.Get(server.URL, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"", "", ""}, keys, "idempotent methods don't need a key")
+}
+
+func TestHTTPClientPerAttemptTimeoutWithCustomDoer(t *testing.T) {
+	t.Parallel()
+
+	count := 0
+	var ctxs []context.Context
+	client := NewClient(
+		WithRetryCount(2),
+		WithPerAttemptTimeout(20*time.Millisecond),
+		WithHTTPClient(heimdallDoerFunc(func(r *http.Request) (*http.Response, error) {
+			count++
+			ctxs = append(ctxs, r.Context())
+			if count == 1 {
+				<-r.Context().Done() // stuck attempt
+				return nil, r.Context().Err()
+			}
+			return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader("ok"))}, nil
+		})),
+	)
+
+	response, err := client.Get("http://localhost", http.Header{})
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+	assert.ErrorIs(t, ctxs[0].Err(), context.DeadlineExceeded)
+
+	require.NoError(t, ctxs[1].Err(), "attempt context should outlive Do until the body is closed")
+	assert.Equal(t, "ok", respBody(t, response))
+	assert.ErrorIs(t, ctxs[1].Err(), context.Canceled)
+}
+
+func TestHTTPClientTotalTimeoutBoundsRetries(t *testing.T) {
+	t.Parallel()
+
+	count := atomic.Int32{}
+	client := NewClient(
+		WithRetryCount(100),
+		WithRetrier(heimdall.NewRetrier(heimdall.NewConstantBackoff(10*time.Millisecond, 0))),
+		WithPerAttemptTimeout(20*time.Millisecond),
+		WithTotalTimeout(100*time.Millisecond),
+		WithHTTPClient(heimdallDoerFunc(func(r *http.Request) (*http.Response, error) {
+			count.Add(1)
+			<-r.Context().Done()
+			return nil, r.Context().Err()
+		})),
+	)
+
+	start := time.Now()
+	response, err := client.Get("http://localhost", http.Header{})
+	assert.Nil(t, response)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), time.Second)
+	assert.Less(t, count.Load(), int32(10))
+}
+
+func TestHTTPClientTotalTimeoutCoversResponseBody(t *testing.T) {
+	t.Parallel()
+
+	var attemptCtx context.Context
+	client := NewClient(
+		WithTotalTimeout(time.Minute),
+		WithHTTPClient(heimdallDoerFunc(func(r *http.Request) (*http.Response, error) {
+			attemptCtx = r.Context()
+			return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader("ok"))}, nil
+		})),
+	)
+
+	response, err := client.Get("http://localhost", http.Header{})
+	require.NoError(t, err)
+	deadline, ok := attemptCtx.Deadline()
+	assert.True(t, ok)
+	assert.WithinDuration(t, time.Now().Add(time.Minute), deadline, time.Second)
+
+	require.NoError(t, attemptCtx.Err())
+	assert.Equal(t, "ok", respBody(t, response))
+	assert.ErrorIs(t, attemptCtx.Err(), context.Canceled)
+}
+
+func TestHTTPClientRetriesExhaustedErrorRecordsAttempts(t *testing.T) {
+	t.Parallel()
+
+	errTimeout := errors.New("timeout")
+	errReset := errors.New("connection reset")
+	results := []func() (*http.Response, error){
+		func() (*http.Response, error) { return nil, errTimeout },
+		func() (*http.Response, error) {
+			return &http.Response{StatusCode: http.StatusBadGateway, Body: io.NopCloser(strings.NewReader(""))}, nil
+		},
+		func() (*http.Response, error) { return nil, errReset },
+	}
+
+	client := NewClient(
+		WithRetryCount(2),
+		WithRetrier(heimdall.NewRetrier(heimdall.NewConstantBackoff(5*time.Millisecond, 0))),
+		WithHTTPClient(heimdallDoerFunc(func(*http.Request) (*http.Response, error) {
+			result := results[0]
+			results = results[1:]
+			return result()
+		})),
+	)
+
+	start := time.Now()
+	response, err := client.Get("http://localhost", http.Header{})
+	assert.Nil(t, response)
+	assert.ErrorIs(t, err, errTimeout)
+	assert.ErrorIs(t, err, errReset)
+
+	var exhausted *heimdall.RetriesExhaustedError
+	require.ErrorAs(t, err, &exhausted)
+	assert.Zero(t, exhausted.StatusCode)
+	assert.NoError(t, exhausted.Err)
+	require.Len(t, exhausted.Attempts, 3)
+
+	assert.Equal(t, errTimeout, exhausted.Attempts[0].Err)
+	assert.Zero(t, exhausted.Attempts[0].Backoff)
+	assert.Equal(t, "request failed", exhausted.Attempts[0].RetryReason)
+	assert.WithinDuration(t, start, exhausted.Attempts[0].Start, 5*time.Millisecond)
+
+	assert.NoError(t, exhausted.Attempts[1].Err)
+	assert.Equal(t, http.StatusBadGateway, exhausted.Attempts[1].StatusCode)
+	assert.Equal(t, 5*time.Millisecond, exhausted.Attempts[1].Backoff)
+	assert.Equal(t, "status code 502", exhausted.Attempts[1].RetryReason)
+	assert.True(t, exhausted.Attempts[1].Start.After(exhausted.Attempts[0].Start))
+
+	assert.Equal(t, errReset, exhausted.Attempts[2].Err)
+	assert.Equal(t, 5*time.Millisecond, exhausted.Attempts[2].Backoff)
+}
+
+func TestHTTPClientRetriesExhaustedErrorRecordsInterruptedBackoff(t *testing.T) {
+	t.Parallel()
+
+	client := NewClient(
+		WithRetryCount(3),
+		WithRetrier(heimdall.NewRetrier(heimdall.NewConstantBackoff(time.Minute, 0))),
+		WithHTTPClient(heimdallDoerFunc(func(r *http.Request) (*http.Response, error) {
+			return nil, errors.New("boom")
+		})),
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+	}()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://localhost", nil)
+	require.NoError(t, err)
+
+	_, err = client.Do(req)
+	assert.ErrorIs(t, err, context.Canceled)
+
+	var exhausted *heimdall.RetriesExhaustedError
+	require.ErrorAs(t, err, &exhausted)
+	assert.Len(t, exhausted.Attempts, 1)
+	assert.Equal(t, context.Canceled, exhausted.Err)
+	assert.Equal(t, "giving up after 1 attempt; attempt 1: boom in 0s; context canceled", strings.ReplaceAll(err.Error(), exhausted.Attempts[0].Duration.String(), "0s"))
+}
+
+func TestHTTPClientRetryableStatusError(t *testing.T) {
+	t.Parallel()
+
+	client := NewClient(
+		WithHTTPTimeout(10*time.Millisecond),
+		WithRetryCount(2),
+		WithRetryableStatusError(),
+	)
+
+	count := atomic.Int32{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		count.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+		_, _ = w.Write([]byte("unavailable"))
+	}))
+	defer server.Close()
+
+	response, err := client.Get(server.URL, http.Header{})
+	require.ErrorIs(t, err, heimdall.ErrRetryableStatusExhausted)
+	assert.Equal(t, int32(3), count.Load())
+
+	var statusErr *heimdall.RetryableStatusError
+	require.ErrorAs(t, err, &statusErr)
+	assert.Equal(t, http.StatusServiceUnavailable, statusErr.StatusCode)
+	assert.Equal(t, 3, statusErr.Attempts)
+
+	require.NotNil(t, response)
+	assert.Equal(t, http.StatusServiceUnavailable, response.StatusCode)
+	assert.Equal(t, "unavailable", respBody(t, response))
+}
+
+func TestHTTPClientRetryableStatusErrorAfterFailedAttempts(t *testing.T) {
+	t.Parallel()
+
+	errTimeout := errors.New("timeout")
+	count := 0
+	client := NewClient(
+		WithRetryCount(1),
+		WithRetryableStatusError(),
+		WithHTTPClient(heimdallDoerFunc(func(*http.Request) (*http.Response, error) {
+			count++
+			if count == 1 {
+				return nil, errTimeout
+			}
+			return &http.Response{StatusCode: http.StatusBadGateway, Body: io.NopCloser(strings.NewReader(""))}, nil
+		})),
+	)
+
+	response, err := client.Get("http://localhost", http.Header{})
+	require.NotNil(t, response)
+	assert.Equal(t, http.StatusBadGateway, response.StatusCode)
+	assert.ErrorIs(t, err, errTimeout)
+	assert.ErrorIs(t, err, heimdall.ErrRetryableStatusExhausted)
+
+	var exhausted *heimdall.RetriesExhaustedError
+	require.ErrorAs(t, err, &exhausted)
+	assert.Equal(t, http.StatusBadGateway, exhausted.StatusCode)
+	assert.Len(t, exhausted.Attempts, 2)
+}
+
+func TestHTTPClientRetryableStatusErrorNotReturnedOnSuccess(t *testing.T) {
+	t.Parallel()
+
+	client := NewClient(
+		WithHTTPTimeout(10*time.Millisecond),
+		WithRetryCount(2),
+		WithRetryableStatusError(),
+	)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	response, err := client.Get(server.URL, http.Header{})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, response.StatusCode)
+}
+
+type stateRecordingRetrier struct {
+	states []heimdall.BackoffState
+}
+
+func (r *stateRecordingRetrier) NextInterval(retry int) time.Duration {
+	return r.NextIntervalState(heimdall.BackoffState{Retry: retry})
+}
+
+func (r *stateRecordingRetrier) NextIntervalState(state heimdall.BackoffState) time.Duration {
+	r.states = append(r.states, state)
+	return time.Duration(state.Retry+1) * time.Millisecond
+}
+
+func TestHTTPClientPassesPreviousBackoffToRetrier(t *testing.T) {
+	t.Parallel()
+
+	retrier := &stateRecordingRetrier{}
+	client := NewClient(
+		WithRetryCount(3),
+		WithRetrier(retrier),
+		WithHTTPClient(heimdallDoerFunc(func(*http.Request) (*http.Response, error) {
+			return &http.Response{StatusCode: http.StatusServiceUnavailable, Body: http.NoBody}, nil
+		})),
+	)
+
+	_, err := client.Get("http://localhost", http.Header{})
+	require.NoError(t, err)
+	assert.Equal(t, []heimdall.BackoffState{
+		{Retry: 0, Previous: 0, Elapsed: 0},
+		{Retry: 1, Previous: time.Millisecond, Elapsed: time.Millisecond},
+		{Retry: 2, Previous: 2 * time.Millisecond, Elapsed: 3 * time.Millisecond},
+	}, retrier.states)
+}
+
+func TestHTTPClientStopsRetryingWhenBackoffStops(t *testing.T) {
+	t.Parallel()
+
+	count := 0
+	client := NewClient(
+		WithRetryCount(5),
+		WithRetrier(heimdall.NewRetrier(heimdall.WithMaxElapsed(heimdall.NewConstantBackoff(time.Millisecond, 0), 2*time.Millisecond))),
+		WithHTTPClient(heimdallDoerFunc(func(*http.Request) (*http.Response, error) {
+			count++
+			return &http.Response{StatusCode: http.StatusServiceUnavailable, Body: http.NoBody}, nil
+		})),
+	)
+
+	response, err := client.Get("http://localhost", http.Header{})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusServiceUnavailable, response.StatusCode)
+	assert.Equal(t, 3, count)
+}
+
+func TestHTTPClientMiddlewareRewritesRequests(t *testing.T) {
+	t.Parallel()
+
+	auth := func(next heimdall.Doer) heimdall.Doer {
+		return heimdall.DoerFunc(func(req *http.Request) (*http.Response, error) {
+			req.Header.Set("Authorization", "Bearer token")
+			return next.Do(req)
+		})
+	}
+
+	var authorizations []string
+	client := NewClient(
+		WithRetryCount(1),
+		WithMiddleware(auth),
+		WithHTTPClient(heimdallDoerFunc(func(req *http.Request) (*http.Response, error) {
+			authorizations = append(authorizations, req.Header.Get("Authorization"))
+			return &http.Response{StatusCode: http.StatusServiceUnavailable, Body: http.NoBody}, nil
+		})),
+	)
+
+	mockPlugin := &MockPlugin{}
+	client.AddPlugin(mockPlugin)
+	mockPlugin.On("OnRequestStart", mock.Anything)
+	mockPlugin.On("OnRequestEnd", mock.Anything, mock.Anything)
+
+	_, err := client.Get("http://localhost", http.Header{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Bearer token", "Bearer token"}, authorizations, "middlewares should run on every attempt")
+
+	pluginRequest, ok := mockPlugin.Calls[0].Arguments[0].(*http.Request)
+	require.True(t, ok)
+	assert.Equal(t, "Bearer token", pluginRequest.Header.Get("Authorization"), "plugins should see the rewritten request")
+}
+
+func TestHTTPClientMiddlewareLeavesCallerHeadersUntouched(t *testing.T) {
+	t.Parallel()
+
+	tagging := func(next heimdall.Doer) heimdall.Doer {
+		return heimdall.DoerFunc(func(req *http.Request) (*http.Response, error) {
+			req.Header.Add("X-Tag", "tagged")
+			return next.Do(req)
+		})
+	}
+
+	var tags [][]string
+	client := NewClient(
+		WithRetryCount(1),
+		WithMiddleware(tagging),
+		WithHTTPClient(heimdallDoerFunc(func(req *http.Request) (*http.Response, error) {
+			tags = append(tags, req.Header.Values("X-Tag"))
+			return &http.Response{StatusCode: http.StatusServiceUnavailable, Body: http.NoBody}, nil
+		})),
+	)
+
+	headers := http.Header{"Accept": {"application/json"}}
+	_, err := client.Get("http://localhost", headers)
+	require.NoError(t, err)
+
+	assert.Equal(t, [][]string{{"tagged"}, {"tagged"}}, tags, "each attempt should start from the caller's headers")
+	assert.Equal(t, http.Header{"Accept": {"application/json"}}, headers)
+}
+
+func TestHTTPClientMiddlewareShortCircuits(t *testing.T) {
+	t.Parallel()
+
+	cached := func(next heimdall.Doer) heimdall.Doer {
+		return heimdall.DoerFunc(func(req *http.Request) (*http.Response, error) {
+			return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader("cached"))}, nil
+		})
+	}
+
+	client := NewClient(
+		WithMiddleware(cached),
+		WithHTTPClient(heimdallDoerFunc(func(*http.Request) (*http.Response, error) {
+			t.Error("the underlying client should not be called")
+			return nil, errors.New("unexpected call")
+		})),
+	)
+
+	response, err := client.Get("http://localhost", http.Header{})
+	require.NoError(t, err)
+
+	body, err := io.ReadAll(response.Body)
+	require.NoError(t, err)
+	assert.Equal(t, "cached", string(body))
+}
+
+func TestHTTPClientMiddlewareWrapsErrors(t *testing.T) {
+	t.Parallel()
+
+	errUpstream := errors.New("upstream unavailable")
+	wrap := func(next heimdall.Doer) heimdall.Doer {
+		return heimdall.DoerFunc(func(req *http.Request) (*http.Response, error) {
+			response, err := next.Do(req)
+			if err != nil {
+				return nil, fmt.Errorf("%w: %w", errUpstream, err)
+			}
+			return response, nil
+		})
+	}
+
+	var order []string
+	tag := func(name string) heimdall.Middleware {
+		return func(next heimdall.Doer) heimdall.Doer {
+			return heimdall.DoerFunc(func(req *http.Request) (*http.Response, error) {
+				order = append(order, name)
+				return next.Do(req)
+			})
+		}
+	}
+
+	client := NewClient(
+		WithMiddleware(tag("outer"), wrap),
+		WithMiddleware(tag("inner")),
+		WithHTTPClient(heimdallDoerFunc(func(*http.Request) (*http.Response, error) {
+			return nil, errors.New("connection refused")
+		})),
+	)
+
+	_, err := client.Get("http://localhost", http.Header{})
+	require.ErrorIs(t, err, errUpstream)
+	assert.Equal(t, []string{"outer", "inner"}, order)
+}
+
+type lifecyclePlugin struct {
+	callStarts       int
+	callEnds         []error
+	callEndAttempts  int
+	retries          []int
+	waits            []time.Duration
+	causes           []heimdall.Attempt
+	giveUps          []error
+	giveUpAttempts   int
+	budgetExhausted  int
+	requestsObserved int
+}
+
+func (p *lifecyclePlugin) OnRequestStart(*http.Request)               {}
+func (p *lifecyclePlugin) OnRequestEnd(*http.Request, *http.Response) { p.requestsObserved++ }
+func (p *lifecyclePlugin) OnError(*http.Request, error)               { p.requestsObserved++ }
+
+func (p *lifecyclePlugin) OnCallStart(*http.Request) {
+	p.callStarts++
+}
+
+func (p *lifecyclePlugin) OnCallEnd(_ *http.Request, _ *http.Response, attempts int, err error) {
+	p.callEndAttempts = attempts
+	p.callEnds = append(p.callEnds, err)
+}
+
+func (p *lifecyclePlugin) OnRetry(_ *http.Request, attempt int, wait time.Duration, cause heimdall.Attempt) {
+	p.retries = append(p.retries, attempt)
+	p.waits = append(p.waits, wait)
+	p.causes = append(p.causes, cause)
+}
+
+func (p *lifecyclePlugin) OnGiveUp(_ *http.Request, attempts int, err error) {
+	p.giveUpAttempts = attempts
+	p.giveUps = append(p.giveUps, err)
+}
+
+func (p *lifecyclePlugin) OnBudgetExhausted(*http.Request) {
+	p.budgetExhausted++
+}
+
+func TestHTTPClientReportsRetriesAndGiveUp(t *testing.T) {
+	t.Parallel()
+
+	errBoom := errors.New("boom")
+	count := 0
+	client := NewClient(
+		WithRetryCount(2),
+		WithRetrier(heimdall.NewRetrier(heimdall.NewConstantBackoff(time.Millisecond, 0))),
+		WithHTTPClient(heimdallDoerFunc(func(*http.Request) (*http.Response, error) {
+			count++
+			if count == 1 {
+				return nil, errBoom
+			}
+			return &http.Response{StatusCode: http.StatusServiceUnavailable, Body: http.NoBody}, nil
+		})),
+	)
+
+	plugin := &lifecyclePlugin{}
+	client.AddPlugin(plugin)
+
+	response, err := client.Get("http://localhost", http.Header{})
+	require.Error(t, err)
+	assert.Equal(t, http.StatusServiceUnavailable, response.StatusCode)
+
+	assert.Equal(t, []int{2, 3}, plugin.retries)
+	assert.Equal(t, []time.Duration{time.Millisecond, time.Millisecond}, plugin.waits)
+	assert.ErrorIs(t, plugin.causes[0].Err, errBoom)
+	assert.Equal(t, http.StatusServiceUnavailable, plugin.causes[1].StatusCode)
+	assert.Equal(t, 3, plugin.requestsObserved)
+
+	assert.Equal(t, 3, plugin.giveUpAttempts)
+	require.Len(t, plugin.giveUps, 1)
+	assert.Equal(t, err, plugin.giveUps[0])
+}
+
+func TestHTTPClientReportsCallStartAndEnd(t *testing.T) {
+	t.Parallel()
+
+	count := 0
+	client := NewClient(
+		WithRetryCount(2),
+		WithHTTPClient(heimdallDoerFunc(func(*http.Request) (*http.Response, error) {
+			count++
+			if count == 1 {
+				return &http.Response{StatusCode: http.StatusServiceUnavailable, Body: http.NoBody}, nil
+			}
+			return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody}, nil
+		})),
+	)
+
+	plugin := &lifecyclePlugin{}
+	client.AddPlugin(plugin)
+
+	_, err := client.Get("http://localhost", http.Header{})
+	require.NoError(t, err)
+	assert.Equal(t, 1, plugin.callStarts)
+	assert.Equal(t, []error{nil}, plugin.callEnds)
+	assert.Equal(t, 2, plugin.callEndAttempts)
+
+	errBoom := errors.New("boom")
+	client = NewClient(WithHTTPClient(heimdallDoerFunc(func(*http.Request) (*http.Response, error) {
+		return nil, errBoom
+	})))
+	plugin = &lifecyclePlugin{}
+	client.AddPlugin(plugin)
+
+	_, err = client.Get("http://localhost", http.Header{})
+	require.ErrorIs(t, err, errBoom)
+	assert.Equal(t, 1, plugin.callStarts)
+	assert.Equal(t, []error{err}, plugin.callEnds)
+	assert.Equal(t, 1, plugin.callEndAttempts)
+}
+
+func TestHTTPClientAttemptsInheritCallStartContext(t *testing.T) {
+	t.Parallel()
+
+	type key struct{}
+	var values []any
+	client := NewClient(
+		WithRetryCount(1),
+		WithHTTPClient(heimdallDoerFunc(func(req *http.Request) (*http.Response, error) {
+			values = append(values, req.Context().Value(key{}))
+			return &http.Response{StatusCode: http.StatusServiceUnavailable, Body: http.NoBody}, nil
+		})),
+	)
+	client.AddPlugin(callContextPlugin(func(req *http.Request) {
+		*req = *req.WithContext(context.WithValue(req.Context(), key{}, "call"))
+	}))
+
+	_, err := client.Get("http://localhost", http.Header{})
+	require.NoError(t, err)
+	assert.Equal(t, []any{"call", "call"}, values)
+}
+
+type callContextPlugin func(*http.Request)
+
+func (p callContextPlugin) OnRequestStart(*http.Request)                        {}
+func (p callContextPlugin) OnRequestEnd(*http.Request, *http.Response)          {}
+func (p callContextPlugin) OnError(*http.Request, error)                        {}
+func (p callContextPlugin) OnCallStart(req *http.Request)                       { p(req) }
+func (p callContextPlugin) OnCallEnd(*http.Request, *http.Response, int, error) {}
+
+func TestHTTPClientReportsGiveUpOnRetryableResponse(t *testing.T) {
+	t.Parallel()
+
+	client := NewClient(
+		WithRetryCount(1),
+		WithHTTPClient(heimdallDoerFunc(func(*http.Request) (*http.Response, error) {
+			return &http.Response{StatusCode: http.StatusServiceUnavailable, Body: http.NoBody}, nil
+		})),
+	)
+
+	plugin := &lifecyclePlugin{}
+	client.AddPlugin(plugin)
+
+	_, err := client.Get("http://localhost", http.Header{})
+	require.NoError(t, err)
+
+	require.Len(t, plugin.giveUps, 1)
+	var statusErr *heimdall.RetryableStatusError
+	require.ErrorAs(t, plugin.giveUps[0], &statusErr)
+	assert.Equal(t, 2, statusErr.Attempts)
+}
+
+func TestHTTPClientDoesNotReportGiveUpOnSuccess(t *testing.T) {
+	t.Parallel()
+
+	client := NewClient(
+		WithRetryCount(1),
+		WithHTTPClient(heimdallDoerFunc(func(*http.Request) (*http.Response, error) {
+			return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody}, nil
+		})),
+	)
+
+	plugin := &lifecyclePlugin{}
+	client.AddPlugin(plugin)
+
+	_, err := client.Get("http://localhost", http.Header{})
+	require.NoError(t, err)
+	assert.Empty(t, plugin.retries)
+	assert.Empty(t, plugin.giveUps)
+}
+
+func TestHTTPClientReportsBudgetExhausted(t *testing.T) {
+	t.Parallel()
+
+	client := NewClient(
+		WithRetryCount(3),
+		WithRetryErrorBudgetPercent(1, 50),
+		WithHTTPClient(heimdallDoerFunc(func(*http.Request) (*http.Response, error) {
+			return nil, errors.New("boom")
+		})),
+	)
+
+	plugin := &lifecyclePlugin{}
+	client.AddPlugin(plugin)
+
+	_, err := client.Get("http://localhost", http.Header{})
+	require.Error(t, err)
+	assert.Equal(t, 1, plugin.budgetExhausted)
+	assert.Less(t, plugin.giveUpAttempts, 4, "retries should stop once the budget is exhausted")
+}
+
+func TestHTTPClientRegisterAndRemovePlugins(t *testing.T) {
+	t.Parallel()
+
+	var order []string
+	tagging := func(name string) heimdall.Plugin {
+		plugin := &MockPlugin{}
+		plugin.On("OnRequestStart", mock.Anything).Run(func(mock.Arguments) { order = append(order, name) })
+		plugin.On("OnRequestEnd", mock.Anything, mock.Anything)
+		return plugin
+	}
+
+	client := NewClient(WithHTTPClient(heimdallDoerFunc(func(*http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody}, nil
+	})))
+
+	defaultPlugin := tagging("default")
+	client.AddPlugin(defaultPlugin)
+	handle := client.RegisterPlugin(tagging("high"), 10)
+	client.RegisterPlugin(tagging("low"), -10)
+
+	_, err := client.Get("http://localhost", http.Header{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"high", "default", "low"}, order)
+
+	order = nil
+	assert.True(t, handle.Remove())
+	assert.True(t, client.RemovePlugin(defaultPlugin))
+	assert.False(t, client.RemovePlugin(defaultPlugin))
+
+	_, err = client.Get("http://localhost", http.Header{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"low"}, order)
+}
+
+type countingPlugin struct {
+	calls atomic.Int32
+}
+
+func (p *countingPlugin) OnRequestStart(*http.Request)               { p.calls.Add(1) }
+func (p *countingPlugin) OnRequestEnd(*http.Request, *http.Response) { p.calls.Add(1) }
+func (p *countingPlugin) OnError(*http.Request, error)               { p.calls.Add(1) }
+
+func TestHTTPClientPluginsChangeWhileInUse(t *testing.T) {
+	t.Parallel()
+
+	client := NewClient(WithHTTPClient(heimdallDoerFunc(func(*http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody}, nil
+	})))
+
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for range 50 {
+				_, err := client.Get("http://localhost", http.Header{})
+				assert.NoError(t, err)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for range 50 {
+				plugin := &countingPlugin{}
+				client.AddPlugin(plugin)
+				client.RemovePlugin(plugin)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+type panickingPlugin struct {
+	hook string
+}
+
+func (p *panickingPlugin) panicOn(hook string) {
+	if p.hook == hook {
+		panic(hook + " failed")
+	}
+}
+
+func (p *panickingPlugin) OnRequestStart(*http.Request)               { p.panicOn("OnRequestStart") }
+func (p *panickingPlugin) OnRequestEnd(*http.Request, *http.Response) { p.panicOn("OnRequestEnd") }
+func (p *panickingPlugin) OnError(*http.Request, error)               { p.panicOn("OnError") }
+
+func (p *panickingPlugin) OnRetry(*http.Request, int, time.Duration, heimdall.Attempt) {
+	p.panicOn("OnRetry")
+}
+
+func TestHTTPClientPluginPanicHandler(t *testing.T) {
+	t.Parallel()
+
+	for _, hook := range []string{"OnRequestStart", "OnRequestEnd", "OnRetry"} {
+		t.Run(hook, func(t *testing.T) {
+			t.Parallel()
+
+			var panics []string
+			count := 0
+			client := NewClient(
+				WithRetryCount(1),
+				WithPluginPanicHandler(func(p heimdall.Plugin, hook string, recovered any) {
+					panics = append(panics, fmt.Sprintf("%s: %v", hook, recovered))
+				}),
+				WithHTTPClient(heimdallDoerFunc(func(*http.Request) (*http.Response, error) {
+					count++
+					if count == 1 {
+						return &http.Response{StatusCode: http.StatusServiceUnavailable, Body: http.NoBody}, nil
+					}
+					return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader("ok"))}, nil
+				})),
+			)
+			plugin := &lifecyclePlugin{}
+			client.AddPlugin(&panickingPlugin{hook: hook})
+			client.AddPlugin(plugin)
+
+			response, err := client.Get("http://localhost", http.Header{})
+			require.NoError(t, err)
+			assert.Equal(t, "ok", respBody(t, response))
+			assert.NotEmpty(t, panics)
+			assert.Equal(t, hook+": "+hook+" failed", panics[0])
+			assert.Equal(t, 2, plugin.requestsObserved, "other plugins should still be called")
+		})
+	}
+}
+
+func TestHTTPClientPluginPanicHandlerOnError(t *testing.T) {
+	t.Parallel()
+
+	var hooks []string
+	client := NewClient(
+		WithPluginPanicHandler(func(p heimdall.Plugin, hook string, recovered any) {
+			hooks = append(hooks, hook)
+		}),
+		WithHTTPClient(heimdallDoerFunc(func(*http.Request) (*http.Response, error) {
+			return nil, errors.New("boom")
+		})),
+	)
+	client.AddPlugin(&panickingPlugin{hook: "OnError"})
+
+	_, err := client.Get("http://localhost", http.Header{})
+	require.EqualError(t, err, "boom")
+	assert.Equal(t, []string{"OnError"}, hooks)
+}
+
+func TestHTTPClientPluginPanicsWithoutHandler(t *testing.T) {
+	t.Parallel()
+
+	client := NewClient(WithHTTPClient(heimdallDoerFunc(func(*http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody}, nil
+	})))
+	client.AddPlugin(&panickingPlugin{hook: "OnRequestEnd"})
+
+	assert.PanicsWithValue(t, "OnRequestEnd failed", func() {
+		_, _ = client.Get("http://localhost", http.Header{})
+	})
+}
+
+func TestHTTPClientSetsAttemptOnContext(t *testing.T) {
+	t.Parallel()
+
+	var attempts []int
+	client := NewClient(
+		WithRetryCount(2),
+		WithHTTPClient(heimdallDoerFunc(func(req *http.Request) (*http.Response, error) {
+			attempt, ok := heimdall.AttemptFromContext(req.Context())
+			assert.True(t, ok)
+			attempts = append(attempts, attempt)
+			return &http.Response{StatusCode: http.StatusServiceUnavailable, Body: http.NoBody}, nil
+		})),
+	)
+
+	_, err := client.Get("http://localhost", http.Header{})
+	require.NoError(t, err)
+	assert.Equal(t, []int{1, 2, 3}, attempts)
+}
+
+func TestHTTPClientSetsCallIDOnContext(t *testing.T) {
+	t.Parallel()
+
+	var callIDs []uint64
+	client := NewClient(
+		WithRetryCount(1),
+		WithHTTPClient(heimdallDoerFunc(func(req *http.Request) (*http.Response, error) {
+			callID, ok := heimdall.CallIDFromContext(req.Context())
+			assert.True(t, ok)
+			callIDs = append(callIDs, callID)
+			return &http.Response{StatusCode: http.StatusServiceUnavailable, Body: http.NoBody}, nil
+		})),
+	)
+
+	_, err := client.Get("http://localhost", http.Header{})
+	require.NoError(t, err)
+	_, err = client.Get("http://localhost", http.Header{})
+	require.NoError(t, err)
+
+	require.Len(t, callIDs, 4)
+	assert.Equal(t, callIDs[0], callIDs[1])
+	assert.Equal(t, callIDs[2], callIDs[3])
+	assert.NotEqual(t, callIDs[0], callIDs[2])
+}
+
+func TestHTTPClientPluginChangesStayWithTheAttempt(t *testing.T) {
+	t.Parallel()
+
+	type key struct{}
+	var values []any
+	client := NewClient(
+		WithRetryCount(1),
+		WithHTTPClient(heimdallDoerFunc(func(req *http.Request) (*http.Response, error) {
+			values = append(values, req.Context().Value(key{}))
+			return &http.Response{StatusCode: http.StatusServiceUnavailable, Body: http.NoBody}, nil
+		})),
+	)
+
+	plugin := &MockPlugin{}
+	plugin.On("OnRequestStart", mock.Anything).Run(func(args mock.Arguments) {
+		req := args.Get(0).(*http.Request)
+		previous, _ := req.Context().Value(key{}).(int)
+		*req = *req.WithContext(context.WithValue(req.Context(), key{}, previous+1))
+	})
+	plugin.On("OnRequestEnd", mock.Anything, mock.Anything)
+	client.AddPlugin(plugin)
+
+	_, err := client.Get("http://localhost", http.Header{})
+	require.NoError(t, err)
+	assert.Equal(t, []any{1, 1}, values)
+}
@@ -0,0 +1,167 @@
+package httpclient
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gojek/heimdall/v8"
+	"github.com/gojek/heimdall/v8/internal"
+)
+
+type hedgeResult struct {
+	response *http.Response
+	err      error
+	panic    *hedgePanic // set if a plugin or middleware panicked during the attempt
+	cancel   context.CancelFunc
+	index    int
+}
+
+// hedgePanic carries a panic recovered in the goroutine of an attempt, to raise it again in the caller's.
+type hedgePanic struct {
+	value any
+}
+
+// hedges tracks the attempts sent for a single hedged attempt.
+type hedges struct {
+	results  chan hedgeResult
+	cancels  []context.CancelFunc
+	inFlight int
+}
+
+// hedgedDo makes an attempt, sending up to maxHedges duplicates of the request whenever the in-flight ones haven't
+// answered within the hedge delay. The first response the retry policy accepts wins, the others are cancelled and
+// drained. If none is accepted, the result of the last one to complete is returned.
+func (c *Client) hedgedDo(request *http.Request, reqGetBody internal.RequestGetBody) (*http.Response, error) {
+	ctx := request.Context()
+	h := &hedges{results: make(chan hedgeResult, c.maxHedges+1)}
+
+	var hedgeTimer <-chan time.Time
+	send := func(req *http.Request) {
+		hedgeCtx, cancel := context.WithCancel(ctx)
+		req = req.WithContext(hedgeCtx)
+		req.Header = req.Header.Clone() // attempts are in flight concurrently, so they can't share headers
+		index := len(h.cancels)
+		h.cancels = append(h.cancels, cancel)
+		h.inFlight++
+
+		go func() {
+			result := hedgeResult{cancel: cancel, index: index}
+			defer func() {
+				if recovered := recover(); recovered != nil {
+					result.panic = &hedgePanic{value: recovered}
+				}
+				h.results <- result
+			}()
+
+			start := c.clock.Now()
+			result.response, result.err = c.send(req)
+			if result.err == nil && c.hedgeLatencies != nil {
+				c.hedgeLatencies.Record(c.clock.Now().Sub(start))
+			}
+		}()
+
+		hedgeTimer = nil
+		if len(h.cancels) <= c.maxHedges {
+			if delay, ok := c.hedgeDelay(); ok {
+				hedgeTimer = c.clock.After(delay)
+			}
+		}
+	}
+
+	send(request)
+
+	var last *hedgeResult
+	for h.inFlight > 0 {
+		select {
+		case result := <-h.results:
+			h.inFlight--
+			if result.panic != nil {
+				h.discard(last, -1)
+				panic(result.panic.value)
+			}
+			if result.err == nil {
+				if retry, _ := c.retryPolicy(ctx, request, result.response, nil); !retry {
+					h.discard(last, result.index)
+					return releaseOnClose(result)
+				}
+			}
+
+			if last != nil {
+				discardHedge(*last)
+			}
+			last = &result
+		case <-hedgeTimer:
+			hedgeTimer = nil
+			if internal.IsCtxDone(ctx) {
+				continue // in-flight attempts are about to fail as well
+			}
+
+			hedge, err := internal.CloneRequest(request, reqGetBody)
+			if err != nil {
+				h.discard(last, -1)
+				return nil, err
+			}
+
+			send(hedge)
+		}
+	}
+
+	return releaseOnClose(*last)
+}
+
+// canHedge reports whether duplicates of the request can be sent: requests with a non-idempotent method (e.g. POST,
+// PATCH) are only hedged when they carry an Idempotency-Key header, so that a server can't act twice on the same call.
+func canHedge(request *http.Request) bool {
+	return heimdall.IsIdempotentMethod(request.Method) || request.Header.Get(heimdall.IdempotencyKeyHeader) != ""
+}
+
+// hedgeDelay returns how long to wait for in-flight attempts before sending a hedge.
+func (c *Client) hedgeDelay() (time.Duration, bool) {
+	if c.hedgeLatencies == nil {
+		return c.hedgeAfter, true
+	}
+
+	return c.hedgeLatencies.Percentile(c.hedgePercentile)
+}
+
+// releaseOnClose hands the result back to the caller, cancelling its context once the response body is closed.
+func releaseOnClose(result hedgeResult) (*http.Response, error) {
+	if result.response == nil || result.response.Body == nil {
+		result.cancel()
+		return result.response, result.err
+	}
+
+	result.response.Body = internal.CancelOnClose(result.response.Body, result.cancel)
+	return result.response, result.err
+}
+
+// discard cancels every attempt but the winning one, and drains the responses of those still in flight
+// along with the given result.
+func (h *hedges) discard(last *hedgeResult, winner int) {
+	for i, cancel := range h.cancels {
+		if i != winner {
+			cancel()
+		}
+	}
+
+	if last != nil {
+		discardHedge(*last)
+	}
+
+	if h.inFlight == 0 {
+		return
+	}
+
+	results, inFlight := h.results, h.inFlight
+	go func() {
+		for range inFlight {
+			discardHedge(<-results)
+		}
+	}()
+}
+
+func discardHedge(result hedgeResult) {
+	result.cancel()
+	internal.DiscardResponse(result.response)
+}
@@ -0,0 +1,230 @@
+package httpclient
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gojek/heimdall/v8"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func slowFirstServer(t *testing.T, count *atomic.Int32, slowDelay time.Duration) *httptest.Server {
+	t.Helper()
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		assert.NoError(t, err)
+
+		if n := count.Add(1); n == 1 {
+			select {
+			case <-time.After(slowDelay):
+			case <-r.Context().Done():
+				return
+			}
+			_, _ = w.Write([]byte("slow:" + string(body)))
+			return
+		}
+		_, _ = w.Write([]byte("fast:" + string(body)))
+	}))
+}
+
+func TestHTTPClientHedgingFirstGoodResponseWins(t *testing.T) {
+	t.Parallel()
+
+	count := atomic.Int32{}
+	server := slowFirstServer(t, &count, 2*time.Second)
+	defer server.Close()
+
+	client := NewClient(
+		WithHTTPTimeout(5*time.Second),
+		WithHedging(20*time.Millisecond, 2),
+	)
+	mockPlugin := &MockPlugin{}
+	mockPlugin.On("OnRequestStart", mock.Anything)
+	mockPlugin.On("OnRequestEnd", mock.Anything, mock.Anything)
+	cancelled := make(chan struct{})
+	mockPlugin.On("OnError", mock.Anything, mock.Anything).Run(func(mock.Arguments) { close(cancelled) })
+	client.AddPlugin(mockPlugin)
+
+	start := time.Now()
+	response, err := client.Put(server.URL, strings.NewReader("payload"), http.Header{})
+	require.NoError(t, err)
+	assert.Less(t, time.Since(start), time.Second)
+	assert.Equal(t, "fast:payload", respBody(t, response))
+
+	select {
+	case <-cancelled:
+	case <-time.After(time.Second):
+		require.Fail(t, "slow attempt should have been cancelled")
+	}
+	mockPlugin.AssertNumberOfCalls(t, "OnRequestStart", 2)
+	mockPlugin.AssertNumberOfCalls(t, "OnRequestEnd", 1)
+	mockPlugin.AssertNumberOfCalls(t, "OnError", 1)
+	assert.Equal(t, int32(2), count.Load())
+}
+
+func TestHTTPClientHedgingSkipsNonIdempotentRequests(t *testing.T) {
+	t.Parallel()
+
+	count := atomic.Int32{}
+	server := slowFirstServer(t, &count, 100*time.Millisecond)
+	defer server.Close()
+
+	client := NewClient(
+		WithHTTPTimeout(5*time.Second),
+		WithHedging(10*time.Millisecond, 2),
+	)
+
+	response, err := client.Post(server.URL, strings.NewReader("payload"), http.Header{})
+	require.NoError(t, err)
+	assert.Equal(t, "slow:payload", respBody(t, response))
+	assert.Equal(t, int32(1), count.Load())
+}
+
+func TestHTTPClientHedgingRequestsWithIdempotencyKey(t *testing.T) {
+	t.Parallel()
+
+	count := atomic.Int32{}
+	server := slowFirstServer(t, &count, 2*time.Second)
+	defer server.Close()
+
+	client := NewClient(
+		WithHTTPTimeout(5*time.Second),
+		WithHedging(20*time.Millisecond, 1),
+		WithIdempotencyKey(nil),
+	)
+
+	response, err := client.Post(server.URL, strings.NewReader("payload"), http.Header{})
+	require.NoError(t, err)
+	assert.Equal(t, "fast:payload", respBody(t, response))
+	assert.Equal(t, int32(2), count.Load())
+}
+
+func TestHTTPClientHedgingNotTriggeredForFastResponses(t *testing.T) {
+	t.Parallel()
+
+	count := atomic.Int32{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		count.Add(1)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client := NewClient(WithHedging(500*time.Millisecond, 1))
+
+	response, err := client.Get(server.URL, http.Header{})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.Equal(t, int32(1), count.Load())
+}
+
+func TestHTTPClientHedgingReturnsLastResultWhenNoneSucceeds(t *testing.T) {
+	t.Parallel()
+
+	count := atomic.Int32{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if count.Add(1) == 1 {
+			time.Sleep(50 * time.Millisecond)
+		}
+		w.WriteHeader(http.StatusServiceUnavailable)
+		_, _ = w.Write([]byte("unavailable"))
+	}))
+	defer server.Close()
+
+	client := NewClient(WithHedging(10*time.Millisecond, 1))
+
+	response, err := client.Get(server.URL, http.Header{})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusServiceUnavailable, response.StatusCode)
+	assert.Equal(t, "unavailable", respBody(t, response))
+	assert.Equal(t, int32(2), count.Load())
+}
+
+func TestHTTPClientPercentileHedging(t *testing.T) {
+	t.Parallel()
+
+	count := atomic.Int32{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if count.Add(1) == 4 {
+			select {
+			case <-time.After(2 * time.Second):
+			case <-r.Context().Done():
+				return
+			}
+		}
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	client := NewClient(
+		WithHTTPTimeout(5*time.Second),
+		WithPercentileHedging(90, 10, 1),
+	)
+
+	for range 3 {
+		response, err := client.Get(server.URL, http.Header{})
+		require.NoError(t, err)
+		assert.Equal(t, "ok", respBody(t, response))
+	}
+	assert.Equal(t, int32(3), count.Load(), "fast responses shouldn't be hedged")
+
+	start := time.Now()
+	response, err := client.Get(server.URL, http.Header{})
+	require.NoError(t, err)
+	assert.Less(t, time.Since(start), time.Second)
+	assert.Equal(t, "ok", respBody(t, response))
+	assert.Equal(t, int32(5), count.Load())
+}
+
+func TestHTTPClientHedgingRaisesPanicsInTheCaller(t *testing.T) {
+	t.Parallel()
+
+	client := NewClient(
+		WithHedging(time.Second, 1),
+		WithHTTPClient(heimdallDoerFunc(func(*http.Request) (*http.Response, error) {
+			return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody}, nil
+		})),
+	)
+	client.AddPlugin(&panickingPlugin{hook: "OnRequestEnd"})
+
+	assert.PanicsWithValue(t, "OnRequestEnd failed", func() {
+		_, _ = client.Get("http://localhost", http.Header{})
+	})
+}
+
+func TestHTTPClientHedgingRaisesPanicsOfDuplicatesInTheCaller(t *testing.T) {
+	t.Parallel()
+
+	count := atomic.Int32{}
+	server := slowFirstServer(t, &count, 2*time.Second)
+	defer server.Close()
+
+	var sent atomic.Int32
+	panicking := func(next heimdall.Doer) heimdall.Doer {
+		return heimdall.DoerFunc(func(req *http.Request) (*http.Response, error) {
+			if sent.Add(1) > 1 {
+				panic("duplicate failed")
+			}
+			return next.Do(req)
+		})
+	}
+
+	client := NewClient(
+		WithHTTPTimeout(5*time.Second),
+		WithHedging(20*time.Millisecond, 1),
+		WithMiddleware(panicking),
+	)
+
+	start := time.Now()
+	assert.PanicsWithValue(t, "duplicate failed", func() {
+		_, _ = client.Get(server.URL, http.Header{})
+	})
+	assert.Less(t, time.Since(start), time.Second, "the panic should not wait for the other attempts")
+}
@@ -20,6 +20,32 @@ func WithHTTPTimeout(timeout time.Duration) Option {
 	}
 }
 
+// WithPerAttemptTimeout bounds each attempt with its own context deadline, so that a single stuck attempt
+// can't use up the time left for retries. Unlike WithHTTPTimeout, it works with any Doer set with WithHTTPClient.
+// The deadline also covers reading the response body.
+func WithPerAttemptTimeout(timeout time.Duration) Option {
+	return func(c *Client) {
+		c.perAttemptTimeout = timeout
+	}
+}
+
+// WithTotalTimeout bounds a call, including all of its attempts and the backoff between them, with a context deadline.
+// The deadline also covers reading the response body.
+func WithTotalTimeout(timeout time.Duration) Option {
+	return func(c *Client) {
+		c.totalTimeout = timeout
+	}
+}
+
+// WithMinAttemptTime sets the minimum time an attempt needs to complete. Retries are skipped with a
+// heimdall.RetryDeadlineError, instead of sleeping in vain, when the backoff plus this time doesn't fit
+// in the time left before the request context deadline.
+func WithMinAttemptTime(d time.Duration) Option {
+	return func(c *Client) {
+		c.minAttemptTime = d
+	}
+}
+
 // WithRetryCount sets the retry count for the hystrixHTTPClient
 func WithRetryCount(retryCount int) Option {
 	return func(c *Client) {
@@ -34,6 +60,31 @@ func WithRetrier(retrier heimdall.Retriable) Option {
 	}
 }
 
+// WithClock sets the clock used for retry backoffs, hedge delays and attempt timings, e.g. a fake clock in tests
+func WithClock(clock heimdall.Clock) Option {
+	return func(c *Client) {
+		c.clock = clock
+	}
+}
+
+// WithMiddleware adds middlewares wrapping every attempt, the first one given being the outermost.
+// Middlewares run for retries and hedges alike, and can rewrite requests, return synthetic responses or wrap errors.
+// Plugins run inside the middlewares, observing requests as they are sent.
+func WithMiddleware(middlewares ...heimdall.Middleware) Option {
+	return func(c *Client) {
+		c.middlewares = append(c.middlewares, middlewares...)
+		c.chainMiddlewares()
+	}
+}
+
+// WithPluginPanicHandler isolates the client from panicking plugins: panics in plugin hooks are recovered and
+// passed to the handler, and the request carries on.
+func WithPluginPanicHandler(handler heimdall.PluginPanicHandler) Option {
+	return func(c *Client) {
+		c.pluginPanicHandler = handler
+	}
+}
+
 // WithHTTPClient sets a custom http client
 func WithHTTPClient(client heimdall.Doer) Option {
 	return func(c *Client) {
@@ -45,6 +96,7 @@ func WithHTTPClient(client heimdall.Doer) Option {
 
 // WithRetryableStatusCodes sets status codes to be retried
 // Note: All 5xx status codes are always eligible for retry, thus not required for WithRetryableStatusCodes option.
+// It has no effect when WithRetryPolicy is used.
 func WithRetryableStatusCodes(statusCodes ...int) Option {
 	return func(c *Client) {
 		codes := append(c.retryableCodes, statusCodes...)
@@ -54,6 +106,78 @@ func WithRetryableStatusCodes(statusCodes ...int) Option {
 	}
 }
 
+// WithRetryAfter makes retries of 429 and 503 responses wait for as long as the server asks through the
+// Retry-After header (or RateLimit-Reset/X-RateLimit-Reset), instead of the interval from the retrier.
+// The requested wait is capped at maxWait, or left uncapped if maxWait is zero or negative. If the wait would outlive the request context deadline,
+// no further retries are made and the last response is returned, along with a heimdall.RetryDeadlineError.
+func WithRetryAfter(maxWait time.Duration) Option {
+	return func(c *Client) {
+		c.honorRetryAfter = true
+		c.maxRetryAfter = maxWait
+	}
+}
+
+// WithRetryableStatusError makes calls which end with a response the retry policy would retry, e.g. a 503
+// after all retries, return a heimdall.RetryableStatusError along with the response, instead of a nil error.
+// The response body is left open and must still be closed by the caller.
+func WithRetryableStatusError() Option {
+	return func(c *Client) {
+		c.retryableStatusError = true
+	}
+}
+
+// WithRetryPolicy sets the policy deciding which failed attempts are retried.
+// It replaces the default policy, so status codes set with WithRetryableStatusCodes no longer apply.
+func WithRetryPolicy(policy heimdall.RetryPolicy) Option {
+	return func(c *Client) {
+		c.retryPolicy = policy
+	}
+}
+
+// WithIdempotentRetries only retries requests with a non-idempotent method (e.g. POST, PATCH)
+// when they carry an Idempotency-Key header, so that a server can't act twice on the same call.
+func WithIdempotentRetries() Option {
+	return func(c *Client) {
+		c.idempotentRetries = true
+	}
+}
+
+// WithIdempotencyKey sets an Idempotency-Key header on requests with a non-idempotent method which don't have one.
+// The key is generated once per call and reused on every attempt. If generate is nil, random UUIDs are used.
+func WithIdempotencyKey(generate func() string) Option {
+	if generate == nil {
+		generate = internal.NewIdempotencyKey
+	}
+
+	return func(c *Client) {
+		c.idempotencyKey = generate
+	}
+}
+
+// WithHedging sends a duplicate of an attempt whenever the in-flight ones haven't answered within delay,
+// up to maxHedges duplicates per attempt. The first response which isn't retried by the retry policy wins,
+// the others are cancelled and drained. Every duplicate is reported to plugins. Requests with a non-idempotent
+// method (e.g. POST, PATCH) are only hedged when they carry an Idempotency-Key header, see WithIdempotencyKey.
+// Hedged attempts run in their own goroutines: a panic of a plugin or middleware is raised again in the caller's
+// goroutine, unless the call already returned with another response.
+func WithHedging(delay time.Duration, maxHedges int) Option {
+	return func(c *Client) {
+		c.maxHedges = maxHedges
+		c.hedgeAfter = delay
+		c.hedgeLatencies = nil
+	}
+}
+
+// WithPercentileHedging works like WithHedging, with the delay being the given percentile (0-100)
+// of the latencies of the last window attempts. No duplicates are sent until a latency has been recorded.
+func WithPercentileHedging(percentile float64, window int, maxHedges int) Option {
+	return func(c *Client) {
+		c.maxHedges = maxHedges
+		c.hedgePercentile = percentile
+		c.hedgeLatencies = internal.NewLatencyTracker(window)
+	}
+}
+
 // WithRetryErrorBudgetToken creates a weighted token retry error budget with the following token details.
 //
 //	maxToken: The maximum/initial token value which is used to calculate token threshold(i.e. maxToken/2)
@@ -1,6 +1,7 @@
 package httpclient
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"testing"
@@ -8,6 +9,7 @@ import (
 
 	"github.com/gojek/heimdall/v8"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestOptionsAreSet(t *testing.T) {
@@ -188,3 +190,90 @@ func ExampleWithRetrier() {
 	// retry attempt 2
 	// error
 }
+
+func TestWithRetryAfter(t *testing.T) {
+	t.Parallel()
+
+	c := NewClient()
+	assert.False(t, c.honorRetryAfter)
+
+	c = NewClient(WithRetryAfter(5 * time.Second))
+	assert.True(t, c.honorRetryAfter)
+	assert.Equal(t, 5*time.Second, c.maxRetryAfter)
+}
+
+func TestWithIdempotencyKeyDefaultsToUUID(t *testing.T) {
+	t.Parallel()
+
+	c := NewClient(WithIdempotencyKey(nil))
+	require.NotNil(t, c.idempotencyKey)
+	assert.Len(t, c.idempotencyKey(), 36)
+}
+
+func TestHedgingOptions(t *testing.T) {
+	t.Parallel()
+
+	c := NewClient(WithHedging(10*time.Millisecond, 2))
+	assert.Equal(t, 2, c.maxHedges)
+	assert.Equal(t, 10*time.Millisecond, c.hedgeAfter)
+	assert.Nil(t, c.hedgeLatencies)
+
+	c = NewClient(WithPercentileHedging(95, 100, 1))
+	assert.Equal(t, 1, c.maxHedges)
+	assert.Equal(t, float64(95), c.hedgePercentile)
+	assert.NotNil(t, c.hedgeLatencies)
+}
+
+func TestTimeoutOptions(t *testing.T) {
+	t.Parallel()
+
+	c := NewClient(
+		WithPerAttemptTimeout(time.Second),
+		WithTotalTimeout(5*time.Second),
+	)
+	assert.Equal(t, time.Second, c.perAttemptTimeout)
+	assert.Equal(t, 5*time.Second, c.totalTimeout)
+}
+
+func TestWithMinAttemptTime(t *testing.T) {
+	t.Parallel()
+
+	c := NewClient(WithMinAttemptTime(50 * time.Millisecond))
+	assert.Equal(t, 50*time.Millisecond, c.minAttemptTime)
+}
+
+type stoppedClock struct {
+	now time.Time
+}
+
+func (c stoppedClock) Now() time.Time { return c.now }
+
+func (c stoppedClock) After(time.Duration) <-chan time.Time {
+	fire := make(chan time.Time, 1)
+	fire <- c.now
+	return fire
+}
+
+func TestWithClock(t *testing.T) {
+	t.Parallel()
+
+	clock := stoppedClock{now: time.Date(2024, time.March, 1, 10, 0, 0, 0, time.UTC)}
+	c := NewClient(
+		WithClock(clock),
+		WithRetryCount(2),
+		WithRetrier(heimdall.NewRetrier(heimdall.NewConstantBackoff(time.Hour, 0))),
+		WithHTTPClient(heimdallDoerFunc(func(*http.Request) (*http.Response, error) {
+			return nil, errors.New("boom")
+		})),
+	)
+
+	_, err := c.Get("http://localhost", http.Header{})
+
+	var exhausted *heimdall.RetriesExhaustedError
+	require.ErrorAs(t, err, &exhausted)
+	require.Len(t, exhausted.Attempts, 3)
+	for _, attempt := range exhausted.Attempts {
+		assert.Equal(t, clock.now, attempt.Start)
+		assert.Zero(t, attempt.Duration)
+	}
+}
@@ -0,0 +1,207 @@
+package hystrix
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gojek/hystrix-go/hystrix"
+)
+
+// State is the state of a hystrix circuit
+type State int
+
+const (
+	// StateClosed lets requests through, opening once too many of them fail
+	StateClosed State = iota
+	// StateOpen rejects requests, until the sleep window passed
+	StateOpen
+	// StateHalfOpen lets the next request through to probe whether the server recovered. hystrix-go doesn't expose
+	// it, so it's approximated from the last failure of the command and the sleep window.
+	StateHalfOpen
+)
+
+// String returns the name of the state
+func (s State) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateOpen:
+		return "open"
+	case StateHalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+// CircuitStats is a snapshot of the health of a hystrix command
+type CircuitStats struct {
+	Command string
+	// State is approximate when half-open, see StateHalfOpen
+	State State
+	// ErrorPercentage and RequestVolume cover the requests of the last 10 seconds, as seen by hystrix
+	ErrorPercentage float64
+	RequestVolume   int
+	// ConcurrentRequests are the requests of the client running under the command
+	ConcurrentRequests    int
+	MaxConcurrentRequests int
+	// Forced tells whether the state was forced with ForceOpen or ForceClose, until ForcedUntil if set
+	Forced      bool
+	ForcedUntil time.Time
+}
+
+// override is a state forced on a command
+type override struct {
+	state State
+	until time.Time // zero if the state is forced until cleared
+}
+
+// circuitOverrides are the states forced on the commands of a client
+type circuitOverrides struct {
+	mu     sync.Mutex
+	forced map[string]override
+}
+
+func (o *circuitOverrides) set(command string, forced override) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.forced == nil {
+		o.forced = map[string]override{}
+	}
+	o.forced[command] = forced
+}
+
+func (o *circuitOverrides) clear(command string) bool {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	_, ok := o.forced[command]
+	delete(o.forced, command)
+	return ok
+}
+
+// get returns the state forced on the command, forgetting it once expired
+func (o *circuitOverrides) get(command string, now time.Time) (override, bool) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	forced, ok := o.forced[command]
+	if ok && !forced.until.IsZero() && !now.Before(forced.until) {
+		delete(o.forced, command)
+		return override{}, false
+	}
+	return forced, ok
+}
+
+// ForceOpen rejects the requests of the client running under the command, as if its circuit was open, for the
+// given duration or until cleared if zero. The requests fail with hystrix.ErrCircuitOpen, or go to the fallback.
+// Commands created per key are named "<command name>:<key>", and can be forced before their first request.
+func (hhc *Client) ForceOpen(command string, ttl time.Duration) {
+	hhc.force(command, StateOpen, ttl)
+}
+
+// ForceClose lets the requests of the client running under the command through, even if its circuit is open,
+// for the given duration or until cleared if zero. The requests bypass hystrix meanwhile, so its timeout and
+// concurrency limit don't apply and the requests aren't part of its stats.
+func (hhc *Client) ForceClose(command string, ttl time.Duration) {
+	hhc.force(command, StateClosed, ttl)
+}
+
+// ClearOverride hands the command back to hystrix, returning false if its state wasn't forced
+func (hhc *Client) ClearOverride(command string) bool {
+	return hhc.overrides.clear(command)
+}
+
+func (hhc *Client) force(command string, state State, ttl time.Duration) {
+	forced := override{state: state}
+	if ttl > 0 {
+		forced.until = hhc.clock.Now().Add(ttl)
+	}
+	hhc.overrides.set(command, forced)
+}
+
+// Circuits returns the stats of the command of the client and of the commands created per key
+func (hhc *Client) Circuits() []CircuitStats {
+	names := hhc.commands.names()
+	slices.Sort(names)
+
+	circuits := make([]CircuitStats, 0, len(names)+1)
+	for _, name := range append([]string{hhc.hystrixCommandName}, names...) {
+		circuits = append(circuits, hhc.circuitStats(name))
+	}
+	return circuits
+}
+
+// Circuit returns the stats of the command, returning false if it isn't the command of the client
+// or one of the commands created per key
+func (hhc *Client) Circuit(command string) (CircuitStats, bool) {
+	if command != hhc.hystrixCommandName && !slices.Contains(hhc.commands.names(), command) {
+		return CircuitStats{}, false
+	}
+	return hhc.circuitStats(command), true
+}
+
+func (hhc *Client) circuitStats(command string) CircuitStats {
+	stats := CircuitStats{
+		Command:               command,
+		State:                 StateClosed,
+		ConcurrentRequests:    int(hhc.inFlight(command).Load()),
+		MaxConcurrentRequests: hhc.maxConcurrentRequests,
+	}
+
+	if forced, ok := hhc.overrides.get(command, hhc.clock.Now()); ok {
+		stats.State = forced.state
+		stats.Forced = true
+		stats.ForcedUntil = forced.until
+	}
+
+	circuit, _, err := hystrix.GetCircuit(command)
+	if err != nil {
+		return stats
+	}
+
+	// hystrix runs on real time, as opposed to the clock of the client
+	now := time.Now()
+	var lastFailure time.Time
+	if s := statsOf(command); s != nil {
+		var errs int
+		stats.RequestVolume, errs, lastFailure = s.snapshot(now)
+		if stats.RequestVolume > 0 {
+			stats.ErrorPercentage = 100 * float64(errs) / float64(stats.RequestVolume)
+		}
+	}
+
+	if !stats.Forced && circuit.IsOpen() {
+		stats.State = StateOpen
+		// an approximation: hystrix lets a probe through once the sleep window passed since it opened or last
+		// probed, which happened at or before the last failure reported, so it may turn half-open earlier
+		if !lastFailure.IsZero() && now.Sub(lastFailure) >= hhc.sleepWindow {
+			stats.State = StateHalfOpen
+		}
+	}
+
+	return stats
+}
+
+// inFlight returns the counter of the requests of the client running under the command
+func (hhc *Client) inFlight(command string) *atomic.Int64 {
+	counter, _ := hhc.running.LoadOrStore(command, &atomic.Int64{})
+	return counter.(*atomic.Int64)
+}
+
+// fallback handles the error of a request whose command was forced open or closed, the way hystrix does
+func (hhc *Client) fallback(ctx context.Context, err error) error {
+	if err == nil || hhc.fallbackFunc == nil {
+		return err
+	}
+
+	if fallbackErr := hhc.fallbackFunc(ctx, err); fallbackErr != nil {
+		return fmt.Errorf("fallback failed with '%w'. run error was '%w'", fallbackErr, err)
+	}
+	return nil
+}
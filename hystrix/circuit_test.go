@@ -0,0 +1,209 @@
+package hystrix
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gojek/heimdall/v8/heimdalltest"
+	"github.com/gojek/hystrix-go/hystrix"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStateString(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "closed", StateClosed.String())
+	assert.Equal(t, "open", StateOpen.String())
+	assert.Equal(t, "half-open", StateHalfOpen.String())
+	assert.Equal(t, "unknown", State(42).String())
+}
+
+func TestHystrixHTTPClientForceOpen(t *testing.T) {
+	t.Parallel()
+
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	clock := heimdalltest.NewFakeClock(time.Now())
+	client := NewClient(WithCommandName("force_open"), WithClock(clock))
+	plugin := &lifecyclePlugin{}
+	client.AddPlugin(plugin)
+
+	client.ForceOpen("force_open", time.Minute)
+
+	response, err := client.Get(server.URL, http.Header{})
+	assert.ErrorIs(t, err, hystrix.ErrCircuitOpen)
+	assert.Nil(t, response)
+	assert.Zero(t, calls.Load())
+	assert.Equal(t, int32(1), plugin.circuitOpen.Load())
+
+	stats, ok := client.Circuit("force_open")
+	require.True(t, ok)
+	assert.Equal(t, StateOpen, stats.State)
+	assert.True(t, stats.Forced)
+	assert.Equal(t, clock.Now().Add(time.Minute), stats.ForcedUntil)
+
+	clock.Advance(time.Minute)
+
+	response, err = client.Get(server.URL, http.Header{})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.Equal(t, int32(1), calls.Load())
+
+	stats, _ = client.Circuit("force_open")
+	assert.False(t, stats.Forced)
+}
+
+func TestHystrixHTTPClientForceOpenCallsFallback(t *testing.T) {
+	t.Parallel()
+
+	var fallbackErr error
+	client := NewClient(
+		WithCommandName("force_open_fallback"),
+		WithFallbackCtxFunc(func(_ context.Context, err error) error {
+			fallbackErr = err
+			return nil
+		}),
+	)
+	client.ForceOpen("force_open_fallback", 0)
+
+	response, err := client.Get("http://force-open.example", http.Header{})
+	require.NoError(t, err)
+	assert.Nil(t, response)
+	assert.ErrorIs(t, fallbackErr, hystrix.ErrCircuitOpen)
+
+	client.ForceOpen("force_open_fallback", 0)
+	client.fallbackFunc = func(_ context.Context, err error) error {
+		return errors.New("fallback is down")
+	}
+
+	_, err = client.Get("http://force-open.example", http.Header{})
+	assert.ErrorContains(t, err, "fallback failed with 'fallback is down'")
+}
+
+func TestHystrixHTTPClientForceClose(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client := NewClient(WithCommandName("force_close"))
+	client.ForceClose("force_close", 0)
+
+	response, err := client.Get(server.URL, http.Header{})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+
+	stats, _ := client.Circuit("force_close")
+	assert.Equal(t, StateClosed, stats.State)
+	assert.True(t, stats.Forced)
+	assert.True(t, stats.ForcedUntil.IsZero())
+
+	assert.True(t, client.ClearOverride("force_close"))
+	assert.False(t, client.ClearOverride("force_close"))
+}
+
+func TestHystrixHTTPClientForcesCommandPerKey(t *testing.T) {
+	t.Parallel()
+
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client := NewClient(
+		WithCommandName("force_per_key"),
+		WithCommandKeyFunc(func(request *http.Request) string {
+			return request.URL.Path
+		}),
+	)
+	client.ForceOpen("force_per_key:/down", time.Minute)
+
+	_, err := client.Get(server.URL+"/down", http.Header{})
+	assert.ErrorIs(t, err, hystrix.ErrCircuitOpen)
+
+	_, err = client.Get(server.URL+"/up", http.Header{})
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func TestHystrixHTTPClientCircuits(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client := NewClient(
+		WithCommandName("circuits"),
+		WithMaxConcurrentRequests(42),
+		WithCommandKeyFunc(func(request *http.Request) string {
+			return request.URL.Path
+		}),
+	)
+
+	for _, path := range []string{"/b", "/a", "/b"} {
+		_, err := client.Get(server.URL+path, http.Header{})
+		require.NoError(t, err)
+	}
+
+	circuits := client.Circuits()
+	require.Len(t, circuits, 3)
+	assert.Equal(t, "circuits", circuits[0].Command)
+	assert.Equal(t, "circuits:/a", circuits[1].Command)
+	assert.Equal(t, "circuits:/b", circuits[2].Command)
+	for _, stats := range circuits {
+		assert.Equal(t, StateClosed, stats.State)
+		assert.Zero(t, stats.ConcurrentRequests)
+		assert.Equal(t, 42, stats.MaxConcurrentRequests)
+	}
+
+	_, ok := client.Circuit("circuits:/c")
+	assert.False(t, ok)
+}
+
+func TestHystrixHTTPClientCircuitConcurrentRequests(t *testing.T) {
+	t.Parallel()
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		close(started)
+		<-release
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client := NewClient(WithCommandName("circuit_concurrency"))
+
+	done := make(chan error)
+	go func() {
+		_, err := client.Get(server.URL, http.Header{})
+		done <- err
+	}()
+
+	<-started
+	stats, _ := client.Circuit("circuit_concurrency")
+	assert.Equal(t, 1, stats.ConcurrentRequests)
+
+	close(release)
+	require.NoError(t, <-done)
+
+	stats, _ = client.Circuit("circuit_concurrency")
+	assert.Zero(t, stats.ConcurrentRequests)
+}
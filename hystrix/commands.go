@@ -0,0 +1,55 @@
+package hystrix
+
+import (
+	"sync"
+	"time"
+)
+
+// commandRegistry keeps the hystrix commands created per key, configuring them on first use
+// and forgetting the idle ones.
+type commandRegistry struct {
+	mu        sync.Mutex
+	lastUsed  map[string]time.Time
+	lastSweep time.Time
+}
+
+// use marks the command as used, configuring it if it's new, and forgets the commands idle for idleTTL,
+// evicting them. Both run under the lock, so that a command can't be used again while it's being evicted.
+func (r *commandRegistry) use(name string, now time.Time, idleTTL time.Duration, configure, evict func(name string)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.lastUsed == nil {
+		r.lastUsed = map[string]time.Time{}
+		r.lastSweep = now
+	}
+
+	if _, ok := r.lastUsed[name]; !ok {
+		configure(name)
+	}
+	r.lastUsed[name] = now
+
+	if idleTTL <= 0 || now.Sub(r.lastSweep) < idleTTL {
+		return
+	}
+
+	for command, used := range r.lastUsed {
+		if now.Sub(used) >= idleTTL {
+			delete(r.lastUsed, command)
+			evict(command)
+		}
+	}
+	r.lastSweep = now
+}
+
+// names returns the names of the commands in use
+func (r *commandRegistry) names() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	names := make([]string, 0, len(r.lastUsed))
+	for name := range r.lastUsed {
+		names = append(names, name)
+	}
+	return names
+}
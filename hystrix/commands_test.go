@@ -0,0 +1,145 @@
+package hystrix
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gojek/heimdall/v8/heimdalltest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCommandRegistryConfiguresCommandsOnFirstUse(t *testing.T) {
+	t.Parallel()
+
+	var configured []string
+	configure := func(name string) { configured = append(configured, name) }
+
+	var registry commandRegistry
+	now := time.Now()
+	registry.use("a", now, time.Minute, configure, func(string) {})
+	registry.use("b", now, time.Minute, configure, func(string) {})
+	registry.use("a", now, time.Minute, configure, func(string) {})
+
+	assert.Equal(t, []string{"a", "b"}, configured)
+	assert.ElementsMatch(t, []string{"a", "b"}, registry.names())
+}
+
+func TestCommandRegistryForgetsIdleCommands(t *testing.T) {
+	t.Parallel()
+
+	var registry commandRegistry
+	var configured, evicted []string
+	configure := func(name string) { configured = append(configured, name) }
+	evict := func(name string) {
+		assert.False(t, registry.mu.TryLock(), "commands should be evicted under the lock")
+		evicted = append(evicted, name)
+	}
+
+	now := time.Now()
+	registry.use("idle", now, time.Minute, configure, evict)
+	registry.use("busy", now, time.Minute, configure, evict)
+
+	registry.use("busy", now.Add(30*time.Second), time.Minute, configure, evict)
+	assert.Empty(t, evicted)
+	registry.use("busy", now.Add(70*time.Second), time.Minute, configure, evict)
+	assert.Equal(t, []string{"idle"}, evicted)
+	assert.Equal(t, []string{"busy"}, registry.names())
+
+	registry.use("idle", now.Add(80*time.Second), time.Minute, configure, evict)
+	assert.Equal(t, []string{"idle", "busy", "idle"}, configured)
+}
+
+func TestCommandRegistryKeepsCommandsWithoutTTL(t *testing.T) {
+	t.Parallel()
+
+	var registry commandRegistry
+	now := time.Now()
+	registry.use("a", now, 0, func(string) {}, func(string) {})
+	registry.use("b", now.Add(time.Hour), 0, func(string) {}, func(string) {})
+
+	assert.ElementsMatch(t, []string{"a", "b"}, registry.names())
+}
+
+func TestHystrixHTTPClientCommandPerHost(t *testing.T) {
+	t.Parallel()
+
+	clock := heimdalltest.NewFakeClock(time.Now())
+	client := NewClient(
+		WithCommandName("per_host"),
+		WithPerHostCommands(),
+		WithIdleCommandTTL(time.Minute),
+		WithClock(clock),
+	)
+
+	first, err := http.NewRequest(http.MethodGet, "http://first.example.com/a", nil)
+	require.NoError(t, err)
+	second, err := http.NewRequest(http.MethodGet, "http://second.example.com:8080/b", nil)
+	require.NoError(t, err)
+
+	assert.Equal(t, "per_host:first.example.com", client.commandName(first))
+	assert.Equal(t, "per_host:second.example.com:8080", client.commandName(second))
+	assert.ElementsMatch(t, []string{"per_host:first.example.com", "per_host:second.example.com:8080"}, client.commands.names())
+
+	clock.Advance(2 * time.Minute)
+	assert.Equal(t, "per_host:first.example.com", client.commandName(first))
+	assert.Equal(t, []string{"per_host:first.example.com"}, client.commands.names())
+}
+
+func TestHystrixHTTPClientCommandKeyFunc(t *testing.T) {
+	t.Parallel()
+
+	client := NewClient(
+		WithCommandName("keyed"),
+		WithCommandKeyFunc(func(request *http.Request) string {
+			return request.Header.Get("X-Backend")
+		}),
+	)
+
+	request, err := http.NewRequest(http.MethodGet, "http://example.com/", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "keyed", client.commandName(request))
+	assert.Empty(t, client.commands.names())
+
+	request.Header.Set("X-Backend", "payments")
+	assert.Equal(t, "keyed:payments", client.commandName(request))
+}
+
+func TestHystrixHTTPClientEvictsIdleCommands(t *testing.T) {
+	t.Parallel()
+
+	clock := heimdalltest.NewFakeClock(time.Now())
+	client := NewClient(
+		WithCommandName("evicted"),
+		WithPerHostCommands(),
+		WithIdleCommandTTL(time.Minute),
+		WithClock(clock),
+	)
+
+	idle, err := http.NewRequest(http.MethodGet, "http://idle.example.com/", nil)
+	require.NoError(t, err)
+	busy, err := http.NewRequest(http.MethodGet, "http://busy.example.com/", nil)
+	require.NoError(t, err)
+
+	client.commandName(idle)
+	client.commandName(busy)
+	client.inFlight("evicted:idle.example.com")
+	client.inFlight("evicted:busy.example.com").Add(1) // a request outliving the TTL
+
+	clock.Advance(2 * time.Minute)
+	client.commandName(busy)
+
+	assert.Equal(t, []string{"evicted:busy.example.com"}, client.commands.names())
+	assert.Nil(t, statsOf("evicted:idle.example.com"), "the stats of an evicted command should be released")
+	assert.NotNil(t, statsOf("evicted:busy.example.com"))
+	_, ok := client.running.Load("evicted:idle.example.com")
+	assert.False(t, ok, "the in-flight counter of an evicted command should be released")
+
+	clock.Advance(2 * time.Minute)
+	client.commandName(idle)
+
+	assert.Equal(t, []string{"evicted:idle.example.com"}, client.commands.names())
+	_, ok = client.running.Load("evicted:busy.example.com")
+	assert.True(t, ok, "the in-flight counter of a running command should be kept")
+}
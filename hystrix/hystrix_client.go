@@ -6,7 +6,8 @@ import (
 	"fmt"
 	"io"
 	"net/http"
-	"slices"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/gojek/heimdall/v8"
@@ -29,11 +30,22 @@ type Client struct {
 	sleepWindow            time.Duration
 	errorPercentThreshold  int
 	fallbackFunc           func(ctx context.Context, err error) error
+	commandKey             func(*http.Request) string
+	idleCommandTTL         time.Duration
+	commands               commandRegistry
+	overrides              circuitOverrides
+	running                sync.Map // command name -> *atomic.Int64, the requests in flight
+	clock                  heimdall.Clock
+	plugins                internal.PluginRegistry
+	pluginPanicHandler     heimdall.PluginPanicHandler
 
 	retrier          heimdall.Retriable
 	retryCount       int
 	retryableCodes   []int
+	retryPolicy      heimdall.RetryPolicy
 	retryErrorBudget *internal.ErrorBudget
+
+	retryableStatusError bool
 }
 
 const (
@@ -43,6 +55,7 @@ const (
 	defaultErrorPercentThreshold  = 25
 	defaultSleepWindow            = 500 * time.Millisecond
 	defaultRequestVolumeThreshold = 10
+	defaultIdleCommandTTL         = 10 * time.Minute
 
 	maxUint = ^uint(0)
 	maxInt  = int(maxUint >> 1)
@@ -60,25 +73,62 @@ func NewClient(opts ...Option) *Client {
 		errorPercentThreshold:  defaultErrorPercentThreshold,
 		sleepWindow:            defaultSleepWindow,
 		requestVolumeThreshold: defaultRequestVolumeThreshold,
+		idleCommandTTL:         defaultIdleCommandTTL,
 		retryCount:             defaultHystrixRetryCount,
 		retrier:                heimdall.NewNoRetrier(),
+		clock:                  heimdall.NewSystemClock(),
 	}
 
 	for _, opt := range opts {
 		opt(&client)
 	}
 
-	hystrix.ConfigureCommand(client.hystrixCommandName, hystrix.CommandConfig{
-		Timeout:                durationToInt(client.hystrixTimeout, time.Millisecond),
-		MaxConcurrentRequests:  client.maxConcurrentRequests,
-		RequestVolumeThreshold: client.requestVolumeThreshold,
-		SleepWindow:            durationToInt(client.sleepWindow, time.Millisecond),
-		ErrorPercentThreshold:  client.errorPercentThreshold,
-	})
+	if client.retryPolicy == nil {
+		client.retryPolicy = heimdall.DefaultRetryPolicy(client.retryableCodes...)
+	}
+
+	client.configureCommand(client.hystrixCommandName)
 
 	return &client
 }
 
+// configureCommand configures the hystrix command with the settings of the client
+func (hhc *Client) configureCommand(name string) {
+	trackStats(name)
+	hystrix.ConfigureCommand(name, hystrix.CommandConfig{
+		Timeout:                durationToInt(hhc.hystrixTimeout, time.Millisecond),
+		MaxConcurrentRequests:  hhc.maxConcurrentRequests,
+		RequestVolumeThreshold: hhc.requestVolumeThreshold,
+		SleepWindow:            durationToInt(hhc.sleepWindow, time.Millisecond),
+		ErrorPercentThreshold:  hhc.errorPercentThreshold,
+	})
+}
+
+// commandName returns the name of the hystrix command running the request, the command of its key if any
+func (hhc *Client) commandName(request *http.Request) string {
+	if hhc.commandKey == nil {
+		return hhc.hystrixCommandName
+	}
+
+	key := hhc.commandKey(request)
+	if key == "" {
+		return hhc.hystrixCommandName
+	}
+
+	name := hhc.hystrixCommandName + ":" + key
+	hhc.commands.use(name, hhc.clock.Now(), hhc.idleCommandTTL, hhc.configureCommand, hhc.evictCommand)
+	return name
+}
+
+// evictCommand releases the state kept for an idle command. hystrix-go can't release the circuit of a single
+// command, so its circuit is kept.
+func (hhc *Client) evictCommand(name string) {
+	untrackStats(name)
+	if counter, ok := hhc.running.Load(name); ok && counter.(*atomic.Int64).Load() == 0 {
+		hhc.running.CompareAndDelete(name, counter)
+	}
+}
+
 func durationToInt(duration, unit time.Duration) int {
 	durationAsNumber := duration / unit
 
@@ -157,7 +207,7 @@ func (hhc *Client) Delete(url string, headers http.Header) (*http.Response, erro
 }
 
 // Do makes an HTTP request with the native `http.Do` interface
-func (hhc *Client) Do(request *http.Request) (*http.Response, error) {
+func (hhc *Client) Do(request *http.Request) (response *http.Response, err error) {
 	if origReqBody := request.Body; origReqBody != nil {
 		defer func() {
 			// close the original request body as internal.SetRequestGetBody wraps body with noop closer.
@@ -165,8 +215,16 @@ func (hhc *Client) Do(request *http.Request) (*http.Response, error) {
 		}()
 	}
 
+	request = request.WithContext(internal.WithCallID(request.Context())) // shared by every attempt of the call
+
+	attempts := 0
+	command := hhc.commandName(request) // every attempt runs under the same command
+	hhc.reportCallStart(request)
+	defer func(call *http.Request) {
+		hhc.reportCallEnd(call, response, attempts, err)
+	}(request)
+
 	var reqGetBody internal.RequestGetBody
-	var err error
 	// Only SetRequestGetBody if retry is enabled to avoid unnecessary overhead for non-retry requests
 	if hhc.retryCount > 0 {
 		if err := internal.SetRequestGetBody(request); err != nil {
@@ -176,71 +234,228 @@ func (hhc *Client) Do(request *http.Request) (*http.Response, error) {
 		reqGetBody = request.GetBody
 	}
 
-	var response *http.Response
+	var backoff, elapsed time.Duration
+	var last heimdall.Attempt
 	for i := 0; i <= hhc.retryCount; i++ {
-		if response != nil {
-			_, _ = io.Copy(io.Discard, response.Body)
-			_ = response.Body.Close()
-		}
-
 		if i > 0 {
-			err = internal.SleepInterruptible(request.Context(), hhc.retrier.NextInterval(i-1))
+			backoff = heimdall.NextInterval(hhc.retrier, heimdall.BackoffState{Retry: i - 1, Previous: backoff, Elapsed: elapsed})
+			if backoff == heimdall.Stop {
+				break
+			}
+			elapsed += backoff
+			hhc.reportRetry(request, i+1, backoff, last)
+
+			if response != nil {
+				_, _ = io.Copy(io.Discard, response.Body)
+				_ = response.Body.Close()
+			}
+
+			err = internal.SleepInterruptible(request.Context(), hhc.clock.After, backoff)
 			if err != nil {
+				hhc.reportGiveUp(request, attempts, err)
 				return nil, err
 			}
 
 			request, err = internal.CloneRequest(request, reqGetBody) // Clone the request to reset the body for retry
 			if err != nil {
+				hhc.reportGiveUp(request, attempts, err)
 				return nil, err
 			}
 		}
 
-		response, err = hhc.hystrixDo(request)
+		attempts++
+		request = request.WithContext(heimdall.ContextWithAttempt(request.Context(), attempts))
+		var circuitOpen bool
+		last = heimdall.Attempt{Start: hhc.clock.Now(), Backoff: backoff}
+		response, circuitOpen, err = hhc.hystrixDo(request, command)
+		last.Duration = hhc.clock.Now().Sub(last.Start)
+		if errors.Is(err, errRetryableCode) {
+			last.StatusCode = response.StatusCode
+		} else {
+			last.Err = err
+		}
+
+		if circuitOpen {
+			hhc.reportCircuitOpen(request)
+		}
+
 		if err == nil || internal.IsCtxDone(request.Context()) {
 			_ = hhc.retryErrorBudget.Success()
 			break
 		}
 
+		if !errors.Is(err, errRetryableCode) {
+			if retry, _ := hhc.retryPolicy(request.Context(), request, nil, err); !retry {
+				break
+			}
+		}
+
 		if hhc.retryErrorBudget.Failure() {
+			if i < hhc.retryCount {
+				hhc.reportBudgetExhausted(request)
+			}
 			break
 		}
 	}
 
 	if err != nil {
 		if errors.Is(err, errRetryableCode) {
+			statusErr := &heimdall.RetryableStatusError{StatusCode: response.StatusCode, Attempts: attempts}
+			hhc.reportGiveUp(request, attempts, statusErr)
+			if hhc.retryableStatusError {
+				return response, statusErr
+			}
 			return response, nil
 		}
 
+		hhc.reportGiveUp(request, attempts, err)
 		return nil, err
 	}
 
 	return response, nil
 }
 
-func (hhc *Client) hystrixDo(request *http.Request) (*http.Response, error) {
+// hystrixDo runs the request under the hystrix command, reporting whether it was rejected by an open circuit,
+// which the fallback hides when it succeeds or wraps when it fails
+func (hhc *Client) hystrixDo(request *http.Request, command string) (*http.Response, bool, error) {
 	var response *http.Response
-	err := hystrix.DoC(request.Context(), hhc.hystrixCommandName, func(_ context.Context) error {
+	run := func(_ context.Context) error {
+		inFlight := hhc.inFlight(command)
+		inFlight.Add(1)
+		defer inFlight.Add(-1)
+
 		resp, doErr := hhc.client.Do(request)
 		if doErr != nil {
 			return doErr
 		}
 		response = resp
 
-		if _, ok := slices.BinarySearch(hhc.retryableCodes, response.StatusCode); ok ||
-			response.StatusCode >= http.StatusInternalServerError {
+		if retry, _ := hhc.retryPolicy(request.Context(), request, response, nil); retry {
 			return errRetryableCode
 		}
 
 		return nil
-	}, hhc.fallbackFunc)
+	}
+
+	var circuitOpen atomic.Bool
+	fallback := hhc.fallbackFunc
+	if fallback != nil {
+		fallback = func(ctx context.Context, err error) error {
+			if errors.Is(err, hystrix.ErrCircuitOpen) {
+				circuitOpen.Store(true)
+			}
+			return hhc.fallbackFunc(ctx, err)
+		}
+	}
+
+	var err error
+	forced, ok := hhc.overrides.get(command, hhc.clock.Now())
+	switch {
+	case ok && forced.state == StateOpen:
+		circuitOpen.Store(true)
+		err = hhc.fallback(request.Context(), hystrix.ErrCircuitOpen)
+	case ok:
+		err = hhc.fallback(request.Context(), run(request.Context()))
+	default:
+		err = hystrix.DoC(request.Context(), command, run, fallback)
+	}
+	if errors.Is(err, hystrix.ErrCircuitOpen) {
+		circuitOpen.Store(true)
+	}
 	if err != nil && !errors.Is(err, errRetryableCode) { // Special handling to avoid data race conditions
-		return nil, err
+		return nil, circuitOpen.Load(), err
 	}
 
-	return response, err
+	return response, circuitOpen.Load(), err
 }
 
 // AddPlugin Adds plugin to client
 func (hhc *Client) AddPlugin(p heimdall.Plugin) {
-	hhc.client.AddPlugin(p)
+	hhc.RegisterPlugin(p, 0)
+}
+
+// RegisterPlugin adds a plugin to the client with the given priority, plugins with a higher priority being
+// called first. Plugins added with AddPlugin have a priority of 0. The returned handle removes the plugin.
+// Plugins can be registered and removed while the client is in use.
+func (hhc *Client) RegisterPlugin(p heimdall.Plugin, priority int) heimdall.PluginHandle {
+	// the lifecycle of calls is reported by the hystrix client, the http client only reports attempts
+	return pluginHandles{
+		hhc.plugins.Add(p, priority),
+		hhc.client.RegisterPlugin(attemptPlugin{Plugin: p}, priority),
+	}
+}
+
+// RemovePlugin removes a plugin from the client, returning false if it wasn't added.
+// Plugins whose type isn't comparable, e.g. funcs, are never found and have to be removed through their handle.
+func (hhc *Client) RemovePlugin(p heimdall.Plugin) bool {
+	// the wrapper is comparable whatever the plugin, so only compare it once the plugin was found to be
+	if !hhc.plugins.Remove(p) {
+		return false
+	}
+	return hhc.client.RemovePlugin(attemptPlugin{Plugin: p})
+}
+
+// attemptPlugin hides the optional interfaces of a plugin from the http client
+type attemptPlugin struct {
+	heimdall.Plugin
+}
+
+// pluginHandles removes a plugin registered with both the hystrix and the http client
+type pluginHandles []heimdall.PluginHandle
+
+// Remove unregisters the plugin, returning false if it was already removed
+func (handles pluginHandles) Remove() bool {
+	removed := true
+	for _, handle := range handles {
+		removed = handle.Remove() && removed
+	}
+	return removed
+}
+
+func (hhc *Client) reportCallStart(request *http.Request) {
+	for _, plugin := range hhc.plugins.Plugins() {
+		if p, ok := plugin.(heimdall.CallPlugin); ok {
+			internal.CallPlugin(hhc.pluginPanicHandler, plugin, "OnCallStart", func() { p.OnCallStart(request) })
+		}
+	}
+}
+
+func (hhc *Client) reportCallEnd(request *http.Request, response *http.Response, attempts int, err error) {
+	for _, plugin := range hhc.plugins.Plugins() {
+		if p, ok := plugin.(heimdall.CallPlugin); ok {
+			internal.CallPlugin(hhc.pluginPanicHandler, plugin, "OnCallEnd", func() { p.OnCallEnd(request, response, attempts, err) })
+		}
+	}
+}
+
+func (hhc *Client) reportRetry(request *http.Request, attempt int, wait time.Duration, cause heimdall.Attempt) {
+	for _, plugin := range hhc.plugins.Plugins() {
+		if p, ok := plugin.(heimdall.RetryPlugin); ok {
+			internal.CallPlugin(hhc.pluginPanicHandler, plugin, "OnRetry", func() { p.OnRetry(request, attempt, wait, cause) })
+		}
+	}
+}
+
+func (hhc *Client) reportGiveUp(request *http.Request, attempts int, err error) {
+	for _, plugin := range hhc.plugins.Plugins() {
+		if p, ok := plugin.(heimdall.GiveUpPlugin); ok {
+			internal.CallPlugin(hhc.pluginPanicHandler, plugin, "OnGiveUp", func() { p.OnGiveUp(request, attempts, err) })
+		}
+	}
+}
+
+func (hhc *Client) reportBudgetExhausted(request *http.Request) {
+	for _, plugin := range hhc.plugins.Plugins() {
+		if p, ok := plugin.(heimdall.BudgetPlugin); ok {
+			internal.CallPlugin(hhc.pluginPanicHandler, plugin, "OnBudgetExhausted", func() { p.OnBudgetExhausted(request) })
+		}
+	}
+}
+
+func (hhc *Client) reportCircuitOpen(request *http.Request) {
+	for _, plugin := range hhc.plugins.Plugins() {
+		if p, ok := plugin.(heimdall.CircuitPlugin); ok {
+			internal.CallPlugin(hhc.pluginPanicHandler, plugin, "OnCircuitOpen", func() { p.OnCircuitOpen(request) })
+		}
+	}
 }
@@ -3,6 +3,7 @@ package hystrix
 import (
 	"bytes"
 	"context"
+	"errors"
 	"io"
 	"net/http"
 	"net/http/httptest"
@@ -871,3 +872,406 @@ func TestHystrixHTTPClientRetriesOnSpecificStatusCodes(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, "{ \"response\": \"ok\" }", string(body))
 }
+
+func TestHystrixHTTPClientRetryPolicy(t *testing.T) {
+	t.Parallel()
+
+	client := NewClient(
+		WithHTTPTimeout(10*time.Millisecond),
+		WithCommandName("retry_policy"),
+		WithHystrixTimeout(10*time.Millisecond),
+		WithRetryPolicy(heimdall.NeverRetryStatusCodes(
+			heimdall.AnyRetryPolicy(heimdall.DefaultRetryPolicy(), heimdall.RetryOnStatusCodes(http.StatusConflict)),
+			http.StatusNotImplemented,
+		)),
+		WithRetryCount(3),
+	)
+
+	var statusCodes []int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(statusCodes[0])
+		statusCodes = statusCodes[1:]
+	}))
+	defer server.Close()
+
+	statusCodes = []int{http.StatusConflict, http.StatusNotImplemented, http.StatusOK}
+	response, err := client.Get(server.URL, http.Header{})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNotImplemented, response.StatusCode)
+	assert.Equal(t, []int{http.StatusOK}, statusCodes, "should stop retrying on 501")
+}
+
+func TestHystrixHTTPClientRetryableStatusError(t *testing.T) {
+	t.Parallel()
+
+	client := NewClient(
+		WithHTTPTimeout(10*time.Millisecond),
+		WithCommandName("retryable_status_error"),
+		WithHystrixTimeout(10*time.Millisecond),
+		WithRetryCount(2),
+		WithRetryableStatusError(),
+	)
+
+	count := atomic.Int32{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		count.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	response, err := client.Get(server.URL, http.Header{})
+	require.ErrorIs(t, err, heimdall.ErrRetryableStatusExhausted)
+
+	var statusErr *heimdall.RetryableStatusError
+	require.ErrorAs(t, err, &statusErr)
+	assert.Equal(t, http.StatusServiceUnavailable, statusErr.StatusCode)
+	assert.Equal(t, 3, statusErr.Attempts)
+	assert.Equal(t, int32(3), count.Load())
+	assert.Equal(t, http.StatusServiceUnavailable, response.StatusCode)
+}
+
+func TestHystrixHTTPClientStopsRetryingWhenBackoffStops(t *testing.T) {
+	t.Parallel()
+
+	client := NewClient(
+		WithHTTPTimeout(10*time.Millisecond),
+		WithCommandName("backoff_stop"),
+		WithHystrixTimeout(10*time.Millisecond),
+		WithRetryCount(5),
+		WithRetrier(heimdall.NewRetrier(heimdall.WithMaxElapsed(heimdall.NewConstantBackoff(time.Millisecond, 0), 2*time.Millisecond))),
+	)
+
+	count := atomic.Int32{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		count.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	response, err := client.Get(server.URL, http.Header{})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusServiceUnavailable, response.StatusCode)
+	assert.Equal(t, int32(3), count.Load())
+}
+
+func TestHystrixHTTPClientMiddleware(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client := NewClient(
+		WithHTTPTimeout(10*time.Millisecond),
+		WithCommandName("middleware"),
+		WithHystrixTimeout(10*time.Millisecond),
+		WithMiddleware(func(next heimdall.Doer) heimdall.Doer {
+			return heimdall.DoerFunc(func(req *http.Request) (*http.Response, error) {
+				req.Header.Set("Authorization", "Bearer token")
+				return next.Do(req)
+			})
+		}),
+	)
+
+	response, err := client.Get(server.URL, http.Header{})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+}
+
+type lifecyclePlugin struct {
+	callStarts  atomic.Int32
+	callEnds    atomic.Int32
+	retries     atomic.Int32
+	giveUps     atomic.Int32
+	circuitOpen atomic.Int32
+}
+
+func (p *lifecyclePlugin) OnRequestStart(*http.Request)               {}
+func (p *lifecyclePlugin) OnRequestEnd(*http.Request, *http.Response) {}
+func (p *lifecyclePlugin) OnError(*http.Request, error)               {}
+
+func (p *lifecyclePlugin) OnCallStart(*http.Request) {
+	p.callStarts.Add(1)
+}
+
+func (p *lifecyclePlugin) OnCallEnd(*http.Request, *http.Response, int, error) {
+	p.callEnds.Add(1)
+}
+
+func (p *lifecyclePlugin) OnRetry(*http.Request, int, time.Duration, heimdall.Attempt) {
+	p.retries.Add(1)
+}
+
+func (p *lifecyclePlugin) OnGiveUp(*http.Request, int, error) {
+	p.giveUps.Add(1)
+}
+
+func (p *lifecyclePlugin) OnCircuitOpen(*http.Request) {
+	p.circuitOpen.Add(1)
+}
+
+// retryCausePlugin records the attempts retried
+type retryCausePlugin struct {
+	lifecyclePlugin
+	causes []heimdall.Attempt
+}
+
+func (p *retryCausePlugin) OnRetry(_ *http.Request, _ int, _ time.Duration, cause heimdall.Attempt) {
+	p.causes = append(p.causes, cause)
+}
+
+func TestHystrixHTTPClientTimesRetriedAttempts(t *testing.T) {
+	t.Parallel()
+
+	client := NewClient(
+		WithHTTPTimeout(time.Second),
+		WithCommandName("lifecycle_retry_cause"),
+		WithHystrixTimeout(time.Second),
+		WithRetryCount(1),
+		WithRetrier(heimdall.NewRetrier(heimdall.NewConstantBackoff(time.Millisecond, 0))),
+	)
+
+	plugin := &retryCausePlugin{}
+	client.AddPlugin(plugin)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(20 * time.Millisecond)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	start := time.Now()
+	_, err := client.Get(server.URL, http.Header{})
+	require.NoError(t, err)
+
+	require.Len(t, plugin.causes, 1)
+	cause := plugin.causes[0]
+	assert.Equal(t, http.StatusServiceUnavailable, cause.StatusCode)
+	assert.False(t, cause.Start.Before(start), "the attempt should be stamped when it was sent")
+	assert.GreaterOrEqual(t, cause.Duration, 20*time.Millisecond)
+}
+
+func TestHystrixHTTPClientReportsRetriesAndGiveUp(t *testing.T) {
+	t.Parallel()
+
+	client := NewClient(
+		WithHTTPTimeout(10*time.Millisecond),
+		WithCommandName("lifecycle_retries"),
+		WithHystrixTimeout(10*time.Millisecond),
+		WithRetryCount(2),
+	)
+
+	plugin := &lifecyclePlugin{}
+	client.AddPlugin(plugin)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	_, err := client.Get(server.URL, http.Header{})
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), plugin.retries.Load())
+	assert.Equal(t, int32(1), plugin.giveUps.Load(), "give up should be reported once per call")
+	assert.Equal(t, int32(1), plugin.callStarts.Load(), "call start should be reported once per call")
+	assert.Equal(t, int32(1), plugin.callEnds.Load(), "call end should be reported once per call")
+}
+
+func TestHystrixHTTPClientReportsCircuitOpen(t *testing.T) {
+	t.Parallel()
+
+	client := NewClient(
+		WithHTTPTimeout(10*time.Millisecond),
+		WithCommandName("lifecycle_circuit_open"),
+		WithHystrixTimeout(10*time.Millisecond),
+		WithRequestVolumeThreshold(1),
+		WithErrorPercentThreshold(1),
+		WithSleepWindow(time.Minute),
+	)
+
+	plugin := &lifecyclePlugin{}
+	client.AddPlugin(plugin)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	assert.Eventually(t, func() bool {
+		_, _ = client.Get(server.URL, http.Header{})
+		return plugin.circuitOpen.Load() > 0
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestHystrixHTTPClientReportsCircuitOpenWithFallback(t *testing.T) {
+	t.Parallel()
+
+	client := NewClient(
+		WithHTTPTimeout(10*time.Millisecond),
+		WithCommandName("lifecycle_circuit_open_fallback"),
+		WithHystrixTimeout(10*time.Millisecond),
+		WithRequestVolumeThreshold(1),
+		WithErrorPercentThreshold(1),
+		WithSleepWindow(time.Minute),
+		WithFallbackFunc(func(error) error { return nil }),
+	)
+
+	plugin := &lifecyclePlugin{}
+	client.AddPlugin(plugin)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	assert.Eventually(t, func() bool {
+		_, _ = client.Get(server.URL, http.Header{})
+		return plugin.circuitOpen.Load() > 0
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestHystrixHTTPClientReportsForcedOpenCircuitWithFallback(t *testing.T) {
+	t.Parallel()
+
+	fallbackErr := errors.New("fallback failed")
+	client := NewClient(
+		WithCommandName("lifecycle_forced_open_fallback"),
+		WithFallbackFunc(func(error) error { return fallbackErr }),
+	)
+	client.ForceOpen("lifecycle_forced_open_fallback", time.Minute)
+
+	plugin := &lifecyclePlugin{}
+	client.AddPlugin(plugin)
+
+	_, err := client.Get("http://localhost", http.Header{})
+	require.Error(t, err)
+	assert.ErrorIs(t, err, hystrix.ErrCircuitOpen)
+	assert.ErrorIs(t, err, fallbackErr)
+	assert.Equal(t, int32(1), plugin.circuitOpen.Load())
+}
+
+func TestHystrixHTTPClientRemovePlugin(t *testing.T) {
+	t.Parallel()
+
+	client := NewClient(
+		WithHTTPTimeout(10*time.Millisecond),
+		WithCommandName("remove_plugin"),
+		WithHystrixTimeout(10*time.Millisecond),
+		WithRetryCount(1),
+	)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	removed := &lifecyclePlugin{}
+	client.AddPlugin(removed)
+	handle := client.RegisterPlugin(&lifecyclePlugin{}, 10)
+
+	assert.True(t, client.RemovePlugin(removed))
+	assert.False(t, client.RemovePlugin(removed))
+	assert.True(t, handle.Remove())
+	assert.False(t, handle.Remove())
+
+	_, err := client.Get(server.URL, http.Header{})
+	require.NoError(t, err)
+	assert.Zero(t, removed.retries.Load())
+	assert.Zero(t, removed.giveUps.Load())
+}
+
+// uncomparablePlugin can't be compared with ==, as it holds a slice
+type uncomparablePlugin struct {
+	names []string
+}
+
+func (p uncomparablePlugin) OnRequestStart(*http.Request)               {}
+func (p uncomparablePlugin) OnRequestEnd(*http.Request, *http.Response) {}
+func (p uncomparablePlugin) OnError(*http.Request, error)               {}
+
+func TestHystrixHTTPClientRemoveUncomparablePlugin(t *testing.T) {
+	t.Parallel()
+
+	client := NewClient(WithCommandName("remove_uncomparable_plugin"))
+	plugin := uncomparablePlugin{names: []string{"uncomparable"}}
+	handle := client.RegisterPlugin(plugin, 0)
+
+	assert.NotPanics(t, func() {
+		assert.False(t, client.RemovePlugin(plugin))
+	})
+	assert.True(t, handle.Remove())
+}
+
+type panickingPlugin struct{}
+
+func (p *panickingPlugin) OnRequestStart(*http.Request)               {}
+func (p *panickingPlugin) OnRequestEnd(*http.Request, *http.Response) { panic("OnRequestEnd failed") }
+func (p *panickingPlugin) OnError(*http.Request, error)               {}
+
+func (p *panickingPlugin) OnGiveUp(*http.Request, int, error) {
+	panic("OnGiveUp failed")
+}
+
+func TestHystrixHTTPClientPluginPanicHandler(t *testing.T) {
+	t.Parallel()
+
+	var panicked []heimdall.Plugin
+	var hooks []string
+	client := NewClient(
+		WithHTTPTimeout(10*time.Millisecond),
+		WithCommandName("plugin_panic_handler"),
+		WithHystrixTimeout(10*time.Millisecond),
+		WithPluginPanicHandler(func(p heimdall.Plugin, hook string, recovered any) {
+			panicked = append(panicked, p)
+			hooks = append(hooks, hook)
+		}),
+	)
+
+	plugin := &panickingPlugin{}
+	client.AddPlugin(plugin)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	response, err := client.Get(server.URL, http.Header{})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusServiceUnavailable, response.StatusCode)
+	assert.Equal(t, []string{"OnRequestEnd", "OnGiveUp"}, hooks)
+	assert.Equal(t, []heimdall.Plugin{plugin, plugin}, panicked, "the plugin should be reported as it was added")
+}
+
+func TestHystrixHTTPClientSetsAttemptOnContext(t *testing.T) {
+	t.Parallel()
+
+	var attempts []int
+	callIDs := map[uint64]bool{}
+	client := NewClient(
+		WithHTTPTimeout(10*time.Millisecond),
+		WithCommandName("attempt_context"),
+		WithHystrixTimeout(10*time.Millisecond),
+		WithRetryCount(2),
+		WithMiddleware(func(next heimdall.Doer) heimdall.Doer {
+			return heimdall.DoerFunc(func(req *http.Request) (*http.Response, error) {
+				attempt, _ := heimdall.AttemptFromContext(req.Context())
+				attempts = append(attempts, attempt)
+				callID, _ := heimdall.CallIDFromContext(req.Context())
+				callIDs[callID] = true
+				return next.Do(req)
+			})
+		}),
+	)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	_, err := client.Get(server.URL, http.Header{})
+	require.NoError(t, err)
+	assert.Equal(t, []int{1, 2, 3}, attempts)
+	assert.Len(t, callIDs, 1) // the attempts made by the inner client share the call of the hystrix client
+}
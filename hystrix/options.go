@@ -2,6 +2,7 @@ package hystrix
 
 import (
 	"context"
+	"net/http"
 	"slices"
 	"time"
 
@@ -27,6 +28,68 @@ func WithHTTPTimeout(timeout time.Duration) Option {
 	}
 }
 
+// WithClock sets the clock used for retry backoffs and for forgetting idle commands, e.g. a fake clock in tests.
+// Hystrix timeouts and sleep windows still run on real time.
+func WithClock(clock heimdall.Clock) Option {
+	return func(c *Client) {
+		c.clock = clock
+		httpclient.WithClock(clock)(c.client)
+	}
+}
+
+// WithMiddleware adds middlewares wrapping every attempt made within the hystrix command
+func WithMiddleware(middlewares ...heimdall.Middleware) Option {
+	return func(c *Client) {
+		httpclient.WithMiddleware(middlewares...)(c.client)
+	}
+}
+
+// WithPluginPanicHandler isolates the client from panicking plugins: panics in plugin hooks are recovered and
+// passed to the handler, and the request carries on.
+func WithPluginPanicHandler(handler heimdall.PluginPanicHandler) Option {
+	return func(c *Client) {
+		c.pluginPanicHandler = handler
+		if handler == nil {
+			httpclient.WithPluginPanicHandler(nil)(c.client)
+			return
+		}
+
+		httpclient.WithPluginPanicHandler(func(p heimdall.Plugin, hook string, recovered any) {
+			if attempt, ok := p.(attemptPlugin); ok {
+				p = attempt.Plugin // hand over the plugin as it was added
+			}
+			handler(p, hook, recovered)
+		})(c.client)
+	}
+}
+
+// WithPerHostCommands runs the requests to every host under a separate hystrix command, so that a failing host
+// only trips its own circuit. See WithCommandKeyFunc.
+func WithPerHostCommands() Option {
+	return WithCommandKeyFunc(func(request *http.Request) string {
+		return request.URL.Host
+	})
+}
+
+// WithCommandKeyFunc runs the requests under a separate hystrix command per key returned by the function,
+// e.g. the host and path template of the request. The command of a key is named "<command name>:<key>", and
+// is created with the settings of the client on first use. Requests with an empty key run under the command
+// set by WithCommandName.
+func WithCommandKeyFunc(key func(*http.Request) string) Option {
+	return func(c *Client) {
+		c.commandKey = key
+	}
+}
+
+// WithIdleCommandTTL sets how long the commands created per key are kept once unused, 10 minutes by default,
+// or forever if zero. The client then releases the state it keeps for them, and configures them again if they're
+// used again. hystrix-go can't release the circuit of a single command, so their circuits are kept.
+func WithIdleCommandTTL(ttl time.Duration) Option {
+	return func(c *Client) {
+		c.idleCommandTTL = ttl
+	}
+}
+
 // WithHystrixTimeout sets hystrix timeout
 func WithHystrixTimeout(timeout time.Duration) Option {
 	return func(c *Client) {
@@ -103,6 +166,7 @@ func WithHTTPClient(client heimdall.Doer) Option {
 
 // WithRetryableStatusCodes sets status codes to be retried
 // Note: All 5xx status codes are always eligible for retry, thus not required for WithRetryableStatusCodes option.
+// It has no effect when WithRetryPolicy is used.
 func WithRetryableStatusCodes(statusCodes ...int) Option {
 	return func(c *Client) {
 		codes := append(c.retryableCodes, statusCodes...)
@@ -112,6 +176,24 @@ func WithRetryableStatusCodes(statusCodes ...int) Option {
 	}
 }
 
+// WithRetryableStatusError makes calls which end with a response the retry policy would retry, e.g. a 503
+// after all retries, return a heimdall.RetryableStatusError along with the response, instead of a nil error.
+// The response body is left open and must still be closed by the caller.
+func WithRetryableStatusError() Option {
+	return func(c *Client) {
+		c.retryableStatusError = true
+	}
+}
+
+// WithRetryPolicy sets the policy deciding which failed attempts are retried.
+// Responses the policy retries are also reported as failures to the circuit breaker.
+// It replaces the default policy, so status codes set with WithRetryableStatusCodes no longer apply.
+func WithRetryPolicy(policy heimdall.RetryPolicy) Option {
+	return func(c *Client) {
+		c.retryPolicy = policy
+	}
+}
+
 // WithRetryErrorBudgetToken creates a weighted token retry error budget with the following token details.
 //
 //	maxToken: The maximum/initial token value which is used to calculate token threshold(i.e. maxToken/2)
@@ -6,6 +6,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/gojek/heimdall/v8"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -43,6 +44,8 @@ func TestOptionsHaveDefaults(t *testing.T) {
 	assert.Equal(t, 25, c.errorPercentThreshold)
 	assert.Equal(t, 500*time.Millisecond, c.sleepWindow)
 	assert.Equal(t, 10, c.requestVolumeThreshold)
+	assert.Equal(t, 10*time.Minute, c.idleCommandTTL)
+	assert.Nil(t, c.commandKey)
 }
 
 func ExampleWithHTTPTimeout() {
@@ -161,3 +164,20 @@ func TestWithHTTPTimeoutOverridesUserHTTPClientTimeout_InverseSeq(t *testing.T)
 	assert.NotNil(t, c)
 	assert.Equal(t, httpTimeout, client.Timeout) // overrides user provided *http.Client
 }
+
+func TestWithClock(t *testing.T) {
+	t.Parallel()
+
+	clock := heimdall.NewSystemClock()
+	c := NewClient(WithCommandName("test-clock"), WithClock(clock))
+
+	assert.Equal(t, clock, c.clock)
+}
+
+func TestWithIdleCommandTTL(t *testing.T) {
+	t.Parallel()
+
+	c := NewClient(WithCommandName("test-idle-ttl"), WithIdleCommandTTL(time.Minute))
+
+	assert.Equal(t, time.Minute, c.idleCommandTTL)
+}
@@ -0,0 +1,128 @@
+package hystrix
+
+import (
+	"sync"
+	"time"
+
+	metricCollector "github.com/gojek/hystrix-go/hystrix/metric_collector"
+)
+
+// statsWindowBuckets is the number of seconds the stats of a command are kept, as hystrix computes
+// error percentages over the last 10 seconds
+const statsWindowBuckets = 10
+
+// commandStats are the requests and errors of a command of the last seconds
+type commandStats struct {
+	mu          sync.Mutex
+	buckets     [statsWindowBuckets]statsBucket
+	lastFailure time.Time // the last request of the command failing or rejected
+}
+
+type statsBucket struct {
+	second   int64
+	requests int
+	errors   int
+}
+
+var (
+	// statsRegistry maps the name of every command configured by a client to its stats
+	statsRegistry     sync.Map
+	registerCollector sync.Once
+)
+
+// trackStats collects the stats of the command, registering the hystrix metric collector on first use
+func trackStats(command string) {
+	registerCollector.Do(func() {
+		metricCollector.Registry.Register(newCommandCollector)
+	})
+	statsRegistry.LoadOrStore(command, &commandStats{})
+}
+
+// untrackStats stops collecting the stats of the command, forgetting them
+func untrackStats(command string) {
+	statsRegistry.Delete(command)
+}
+
+// statsOf returns the stats of the command, or nil if they aren't collected
+func statsOf(command string) *commandStats {
+	stats, ok := statsRegistry.Load(command)
+	if !ok {
+		return nil
+	}
+	return stats.(*commandStats)
+}
+
+// newCommandCollector returns the metric collector of a hystrix circuit, which is only collecting for the
+// commands configured by clients
+func newCommandCollector(name string) metricCollector.MetricCollector {
+	if statsOf(name) == nil {
+		return noopCollector{}
+	}
+	return commandCollector(name)
+}
+
+// commandCollector passes the metrics of a command on to its current stats, so that a command evicted
+// and used again while its circuit is kept by hystrix gets new stats
+type commandCollector string
+
+func (c commandCollector) Update(r metricCollector.MetricResult) {
+	if stats := statsOf(string(c)); stats != nil {
+		stats.update(r)
+	}
+}
+
+func (c commandCollector) Reset() {
+	if stats := statsOf(string(c)); stats != nil {
+		stats.reset()
+	}
+}
+
+type noopCollector struct{}
+
+func (noopCollector) Update(metricCollector.MetricResult) {}
+
+func (noopCollector) Reset() {}
+
+// update records the result of a request of the command
+func (s *commandStats) update(r metricCollector.MetricResult) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	second := now.Unix()
+	b := &s.buckets[second%statsWindowBuckets]
+	if b.second != second {
+		*b = statsBucket{second: second}
+	}
+
+	b.requests += int(r.Attempts)
+	b.errors += int(r.Errors)
+	if r.Errors > 0 {
+		s.lastFailure = now
+	}
+}
+
+// reset forgets the stats of the command, hystrix resetting them when the circuit closes
+func (s *commandStats) reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.buckets = [statsWindowBuckets]statsBucket{}
+	s.lastFailure = time.Time{}
+}
+
+// snapshot returns the requests and errors of the last seconds, and the time of the last failure
+func (s *commandStats) snapshot(now time.Time) (requests, errors int, lastFailure time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	second := now.Unix()
+	for _, b := range s.buckets {
+		if b.second > second-statsWindowBuckets && b.second <= second {
+			requests += b.requests
+			errors += b.errors
+		}
+	}
+
+	return requests, errors, s.lastFailure
+}
@@ -0,0 +1,64 @@
+package hystrix
+
+import (
+	"testing"
+	"time"
+
+	metricCollector "github.com/gojek/hystrix-go/hystrix/metric_collector"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCommandStatsKeepTheLastSeconds(t *testing.T) {
+	t.Parallel()
+
+	stats := &commandStats{}
+	stats.update(metricCollector.MetricResult{Attempts: 1, Successes: 1})
+	stats.update(metricCollector.MetricResult{Attempts: 1, Errors: 1, Failures: 1})
+	stats.update(metricCollector.MetricResult{Attempts: 1, Errors: 1, ShortCircuits: 1})
+
+	requests, errors, lastFailure := stats.snapshot(time.Now())
+	assert.Equal(t, 3, requests)
+	assert.Equal(t, 2, errors)
+	assert.False(t, lastFailure.IsZero())
+
+	requests, errors, _ = stats.snapshot(time.Now().Add(statsWindowBuckets * time.Second))
+	assert.Zero(t, requests)
+	assert.Zero(t, errors)
+}
+
+func TestCommandStatsReset(t *testing.T) {
+	t.Parallel()
+
+	stats := &commandStats{}
+	stats.update(metricCollector.MetricResult{Attempts: 1, Errors: 1, Timeouts: 1})
+	stats.reset()
+
+	requests, errors, lastFailure := stats.snapshot(time.Now())
+	assert.Zero(t, requests)
+	assert.Zero(t, errors)
+	assert.True(t, lastFailure.IsZero())
+}
+
+func TestCommandCollectorOnlyCollectsTrackedCommands(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, noopCollector{}, newCommandCollector("untracked_command"))
+
+	trackStats("tracked_command")
+	collector := newCommandCollector("tracked_command")
+	collector.Update(metricCollector.MetricResult{Attempts: 1, Errors: 1})
+
+	requests, errors, _ := statsOf("tracked_command").snapshot(time.Now())
+	assert.Equal(t, 1, requests)
+	assert.Equal(t, 1, errors)
+
+	untrackStats("tracked_command")
+	assert.Nil(t, statsOf("tracked_command"))
+	collector.Update(metricCollector.MetricResult{Attempts: 1}) // the circuit is kept by hystrix
+
+	trackStats("tracked_command")
+	collector.Update(metricCollector.MetricResult{Attempts: 1})
+	requests, errors, _ = statsOf("tracked_command").snapshot(time.Now())
+	assert.Equal(t, 1, requests, "a command tracked again gets new stats")
+	assert.Zero(t, errors)
+}
@@ -0,0 +1,33 @@
+package internal
+
+import (
+	"context"
+	"io"
+	"net/http"
+)
+
+// CancelOnClose wraps body so that cancel is called once the body is closed.
+// It is used to release the context of an attempt only after the caller is done with the response.
+func CancelOnClose(body io.ReadCloser, cancel context.CancelFunc) io.ReadCloser {
+	return &cancelOnCloseBody{ReadCloser: body, cancel: cancel}
+}
+
+type cancelOnCloseBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelOnCloseBody) Close() error {
+	defer b.cancel()
+	return b.ReadCloser.Close()
+}
+
+// DiscardResponse drains and closes the response body so that the underlying connection can be reused.
+func DiscardResponse(response *http.Response) {
+	if response == nil || response.Body == nil {
+		return
+	}
+
+	_, _ = io.Copy(io.Discard, response.Body)
+	_ = response.Body.Close()
+}
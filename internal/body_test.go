@@ -0,0 +1,50 @@
+package internal
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type trackingBody struct {
+	io.Reader
+	closed bool
+}
+
+func (b *trackingBody) Close() error {
+	b.closed = true
+	return nil
+}
+
+func TestCancelOnClose(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	inner := &trackingBody{Reader: strings.NewReader("payload")}
+	body := CancelOnClose(inner, cancel)
+
+	assert.Equal(t, "payload", readBody(t, body))
+	assert.True(t, inner.closed)
+	assert.ErrorIs(t, ctx.Err(), context.Canceled)
+}
+
+func TestDiscardResponse(t *testing.T) {
+	t.Parallel()
+
+	reader := strings.NewReader("payload")
+	inner := &trackingBody{Reader: reader}
+	DiscardResponse(&http.Response{Body: inner})
+
+	assert.True(t, inner.closed)
+	assert.Zero(t, reader.Len())
+
+	require.NotPanics(t, func() {
+		DiscardResponse(nil)
+		DiscardResponse(&http.Response{})
+	})
+}
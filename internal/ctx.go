@@ -1,6 +1,13 @@
 package internal
 
-import "context"
+import (
+	"context"
+	"sync/atomic"
+
+	"github.com/gojek/heimdall/v8"
+)
+
+var lastCallID atomic.Uint64
 
 func IsCtxDone(ctx context.Context) bool {
 	select {
@@ -10,3 +17,13 @@ func IsCtxDone(ctx context.Context) bool {
 		return false
 	}
 }
+
+// WithCallID returns a copy of ctx carrying a new call identifier, unless it already carries one,
+// e.g. set by an outer client.
+func WithCallID(ctx context.Context) context.Context {
+	if _, ok := heimdall.CallIDFromContext(ctx); ok {
+		return ctx
+	}
+
+	return heimdall.ContextWithCallID(ctx, lastCallID.Add(1))
+}
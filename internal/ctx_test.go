@@ -5,6 +5,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/gojek/heimdall/v8"
 	"github.com/gojek/heimdall/v8/internal"
 	"github.com/stretchr/testify/assert"
 )
@@ -25,3 +26,16 @@ func TestIsCtxDone(t *testing.T) {
 	time.Sleep(12 * time.Millisecond)
 	assert.True(t, internal.IsCtxDone(ctx))
 }
+
+func TestWithCallID(t *testing.T) {
+	t.Parallel()
+
+	first, ok := heimdall.CallIDFromContext(internal.WithCallID(context.Background()))
+	assert.True(t, ok)
+	second, _ := heimdall.CallIDFromContext(internal.WithCallID(context.Background()))
+	assert.NotEqual(t, first, second)
+
+	ctx := heimdall.ContextWithCallID(context.Background(), 42)
+	id, _ := heimdall.CallIDFromContext(internal.WithCallID(ctx))
+	assert.Equal(t, uint64(42), id)
+}
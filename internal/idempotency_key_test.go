@@ -0,0 +1,19 @@
+package internal_test
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/gojek/heimdall/v8/internal"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewIdempotencyKey(t *testing.T) {
+	t.Parallel()
+
+	uuidV4 := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+
+	key := internal.NewIdempotencyKey()
+	assert.Regexp(t, uuidV4, key)
+	assert.NotEqual(t, key, internal.NewIdempotencyKey())
+}
@@ -0,0 +1,59 @@
+package internal
+
+import (
+	"math"
+	"slices"
+	"sync"
+	"time"
+)
+
+// LatencyTracker keeps a fixed size window of the most recent latencies.
+type LatencyTracker struct {
+	mx      sync.Mutex
+	samples []time.Duration
+	next    int
+	full    bool
+}
+
+// NewLatencyTracker creates a LatencyTracker which keeps the given number of most recent latencies.
+func NewLatencyTracker(window int) *LatencyTracker {
+	if window < 1 {
+		window = 1
+	}
+
+	return &LatencyTracker{samples: make([]time.Duration, window)}
+}
+
+// Record adds a latency to the window, replacing the oldest one once the window is full.
+func (lt *LatencyTracker) Record(d time.Duration) {
+	lt.mx.Lock()
+	defer lt.mx.Unlock()
+
+	lt.samples[lt.next] = d
+	lt.next = (lt.next + 1) % len(lt.samples)
+	if lt.next == 0 {
+		lt.full = true
+	}
+}
+
+// Percentile returns the latency at the given percentile (0-100) of the window using the nearest-rank method.
+// Returns false if no latency has been recorded yet.
+func (lt *LatencyTracker) Percentile(p float64) (time.Duration, bool) {
+	lt.mx.Lock()
+	n := lt.next
+	if lt.full {
+		n = len(lt.samples)
+	}
+	sorted := slices.Clone(lt.samples[:n])
+	lt.mx.Unlock()
+
+	if n == 0 {
+		return 0, false
+	}
+
+	slices.Sort(sorted)
+	rank := int(math.Ceil(p / 100 * float64(n)))
+	rank = min(max(rank, 1), n)
+
+	return sorted[rank-1], true
+}
@@ -0,0 +1,66 @@
+package internal_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gojek/heimdall/v8/internal"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLatencyTrackerEmpty(t *testing.T) {
+	t.Parallel()
+
+	_, ok := internal.NewLatencyTracker(10).Percentile(99)
+	assert.False(t, ok)
+}
+
+func TestLatencyTrackerPercentile(t *testing.T) {
+	t.Parallel()
+
+	lt := internal.NewLatencyTracker(100)
+	for i := 100; i >= 1; i-- {
+		lt.Record(time.Duration(i) * time.Millisecond)
+	}
+
+	p, ok := lt.Percentile(50)
+	assert.True(t, ok)
+	assert.Equal(t, 50*time.Millisecond, p)
+
+	p, _ = lt.Percentile(99)
+	assert.Equal(t, 99*time.Millisecond, p)
+
+	p, _ = lt.Percentile(100)
+	assert.Equal(t, 100*time.Millisecond, p)
+
+	p, _ = lt.Percentile(0)
+	assert.Equal(t, time.Millisecond, p)
+}
+
+func TestLatencyTrackerKeepsMostRecentWindow(t *testing.T) {
+	t.Parallel()
+
+	lt := internal.NewLatencyTracker(3)
+	lt.Record(time.Second)
+	lt.Record(time.Second)
+	lt.Record(time.Second)
+	lt.Record(time.Millisecond)
+	lt.Record(2 * time.Millisecond)
+	lt.Record(3 * time.Millisecond)
+
+	p, ok := lt.Percentile(100)
+	assert.True(t, ok)
+	assert.Equal(t, 3*time.Millisecond, p)
+}
+
+func TestLatencyTrackerInvalidWindow(t *testing.T) {
+	t.Parallel()
+
+	lt := internal.NewLatencyTracker(0)
+	lt.Record(time.Millisecond)
+	lt.Record(2 * time.Millisecond)
+
+	p, ok := lt.Percentile(50)
+	assert.True(t, ok)
+	assert.Equal(t, 2*time.Millisecond, p)
+}
@@ -0,0 +1,16 @@
+package internal
+
+import "github.com/gojek/heimdall/v8"
+
+// CallPlugin calls a hook of the plugin. If a panic handler is given, panics are recovered and passed to it.
+func CallPlugin(handler heimdall.PluginPanicHandler, p heimdall.Plugin, hook string, call func()) {
+	if handler != nil {
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				handler(p, hook, recovered)
+			}
+		}()
+	}
+
+	call()
+}
@@ -0,0 +1,111 @@
+package internal
+
+import (
+	"reflect"
+	"slices"
+	"sync"
+	"sync/atomic"
+
+	"github.com/gojek/heimdall/v8"
+)
+
+type pluginEntry struct {
+	id       uint64
+	priority int
+	plugin   heimdall.Plugin
+}
+
+type pluginSnapshot struct {
+	entries []pluginEntry
+	plugins []heimdall.Plugin
+}
+
+// PluginRegistry is a list of plugins which is safe for concurrent use. Changes copy the list, so that
+// the snapshots returned by Plugins can be ranged over without locking while plugins are added or removed.
+type PluginRegistry struct {
+	mu       sync.Mutex // serialises writers
+	snapshot atomic.Pointer[pluginSnapshot]
+	nextID   uint64
+}
+
+// Plugins returns the registered plugins, by decreasing priority then in order of registration.
+// The returned slice must not be modified.
+func (r *PluginRegistry) Plugins() []heimdall.Plugin {
+	if snapshot := r.snapshot.Load(); snapshot != nil {
+		return snapshot.plugins
+	}
+
+	return nil
+}
+
+// Add registers a plugin with the given priority. Plugins with a higher priority are called first.
+func (r *PluginRegistry) Add(p heimdall.Plugin, priority int) heimdall.PluginHandle {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.nextID++
+	entry := pluginEntry{id: r.nextID, priority: priority, plugin: p}
+
+	entries := r.entries()
+	i, _ := slices.BinarySearchFunc(entries, priority, func(e pluginEntry, priority int) int {
+		if e.priority >= priority {
+			return -1 // insert after the plugins registered with the same priority
+		}
+		return 1
+	})
+	r.store(slices.Insert(slices.Clone(entries), i, entry))
+
+	return &pluginHandle{registry: r, id: entry.id}
+}
+
+// Remove unregisters the first registration of the given plugin, returning false if it isn't registered.
+// Plugins are compared with ==, so plugins whose type isn't comparable, e.g. funcs or structs holding slices,
+// are never found and have to be removed through their handle.
+func (r *PluginRegistry) Remove(p heimdall.Plugin) bool {
+	if t := reflect.TypeOf(p); t != nil && !t.Comparable() {
+		return false
+	}
+
+	return r.removeFunc(func(e pluginEntry) bool { return e.plugin == p })
+}
+
+func (r *PluginRegistry) removeFunc(match func(pluginEntry) bool) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entries := r.entries()
+	i := slices.IndexFunc(entries, match)
+	if i < 0 {
+		return false
+	}
+
+	r.store(slices.Delete(slices.Clone(entries), i, i+1))
+	return true
+}
+
+func (r *PluginRegistry) entries() []pluginEntry {
+	if snapshot := r.snapshot.Load(); snapshot != nil {
+		return snapshot.entries
+	}
+
+	return nil
+}
+
+func (r *PluginRegistry) store(entries []pluginEntry) {
+	plugins := make([]heimdall.Plugin, len(entries))
+	for i, e := range entries {
+		plugins[i] = e.plugin
+	}
+
+	r.snapshot.Store(&pluginSnapshot{entries: entries, plugins: plugins})
+}
+
+type pluginHandle struct {
+	registry *PluginRegistry
+	id       uint64
+}
+
+// Remove unregisters the plugin, returning false if it was already removed
+func (h *pluginHandle) Remove() bool {
+	return h.registry.removeFunc(func(e pluginEntry) bool { return e.id == h.id })
+}
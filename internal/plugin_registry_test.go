@@ -0,0 +1,128 @@
+package internal_test
+
+import (
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/gojek/heimdall/v8"
+	"github.com/gojek/heimdall/v8/internal"
+	"github.com/stretchr/testify/assert"
+)
+
+type namedPlugin struct {
+	name string
+}
+
+func (p *namedPlugin) OnRequestStart(*http.Request)               {}
+func (p *namedPlugin) OnRequestEnd(*http.Request, *http.Response) {}
+func (p *namedPlugin) OnError(*http.Request, error)               {}
+
+func names(plugins []heimdall.Plugin) []string {
+	result := make([]string, len(plugins))
+	for i, p := range plugins {
+		result[i] = p.(*namedPlugin).name
+	}
+	return result
+}
+
+func TestPluginRegistryOrdersByPriority(t *testing.T) {
+	t.Parallel()
+
+	var registry internal.PluginRegistry
+	assert.Empty(t, registry.Plugins())
+
+	registry.Add(&namedPlugin{"first"}, 0)
+	registry.Add(&namedPlugin{"low"}, -10)
+	registry.Add(&namedPlugin{"high"}, 10)
+	registry.Add(&namedPlugin{"second"}, 0)
+	registry.Add(&namedPlugin{"higher"}, 20)
+
+	assert.Equal(t, []string{"higher", "high", "first", "second", "low"}, names(registry.Plugins()))
+}
+
+func TestPluginRegistryRemove(t *testing.T) {
+	t.Parallel()
+
+	var registry internal.PluginRegistry
+	first, second := &namedPlugin{"first"}, &namedPlugin{"second"}
+	registry.Add(first, 0)
+	registry.Add(second, 0)
+	registry.Add(first, 0)
+
+	snapshot := registry.Plugins()
+
+	assert.True(t, registry.Remove(first))
+	assert.Equal(t, []string{"second", "first"}, names(registry.Plugins()))
+	assert.Equal(t, []string{"first", "second", "first"}, names(snapshot), "snapshots should not change")
+
+	assert.True(t, registry.Remove(first))
+	assert.False(t, registry.Remove(first))
+	assert.Equal(t, []string{"second"}, names(registry.Plugins()))
+}
+
+// uncomparablePlugin can't be compared with ==, as it holds a slice
+type uncomparablePlugin struct {
+	names []string
+}
+
+func (p uncomparablePlugin) OnRequestStart(*http.Request)               {}
+func (p uncomparablePlugin) OnRequestEnd(*http.Request, *http.Response) {}
+func (p uncomparablePlugin) OnError(*http.Request, error)               {}
+
+func TestPluginRegistryRemoveUncomparablePlugin(t *testing.T) {
+	t.Parallel()
+
+	var registry internal.PluginRegistry
+	plugin := uncomparablePlugin{names: []string{"uncomparable"}}
+	handle := registry.Add(plugin, 0)
+
+	assert.NotPanics(t, func() {
+		assert.False(t, registry.Remove(plugin))
+	})
+	assert.Len(t, registry.Plugins(), 1)
+
+	assert.True(t, handle.Remove())
+	assert.Empty(t, registry.Plugins())
+}
+
+func TestPluginRegistryHandle(t *testing.T) {
+	t.Parallel()
+
+	var registry internal.PluginRegistry
+	plugin := &namedPlugin{"plugin"}
+	registry.Add(plugin, 0)
+	handle := registry.Add(plugin, 5)
+	registry.Add(&namedPlugin{"other"}, 0)
+
+	assert.True(t, handle.Remove())
+	assert.False(t, handle.Remove())
+	assert.Equal(t, []string{"plugin", "other"}, names(registry.Plugins()), "only the registration of the handle should be removed")
+}
+
+func TestPluginRegistryConcurrentUse(t *testing.T) {
+	t.Parallel()
+
+	var registry internal.PluginRegistry
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for range 100 {
+				registry.Add(&namedPlugin{"plugin"}, 0).Remove()
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for range 100 {
+				for _, p := range registry.Plugins() {
+					p.OnRequestStart(nil)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Empty(t, registry.Plugins())
+}
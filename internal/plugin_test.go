@@ -0,0 +1,45 @@
+package internal_test
+
+import (
+	"testing"
+
+	"github.com/gojek/heimdall/v8"
+	"github.com/gojek/heimdall/v8/internal"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCallPlugin(t *testing.T) {
+	t.Parallel()
+
+	plugin := &namedPlugin{"plugin"}
+	called := false
+	internal.CallPlugin(nil, plugin, "OnRequestStart", func() { called = true })
+	assert.True(t, called)
+}
+
+func TestCallPluginRecoversPanics(t *testing.T) {
+	t.Parallel()
+
+	plugin := &namedPlugin{"plugin"}
+	var panicked heimdall.Plugin
+	var hook string
+	var recovered any
+	handler := func(p heimdall.Plugin, h string, r any) {
+		panicked, hook, recovered = p, h, r
+	}
+
+	assert.NotPanics(t, func() {
+		internal.CallPlugin(handler, plugin, "OnRequestEnd", func() { panic("boom") })
+	})
+	assert.Same(t, plugin, panicked)
+	assert.Equal(t, "OnRequestEnd", hook)
+	assert.Equal(t, "boom", recovered)
+}
+
+func TestCallPluginWithoutHandlerPanics(t *testing.T) {
+	t.Parallel()
+
+	assert.PanicsWithValue(t, "boom", func() {
+		internal.CallPlugin(nil, &namedPlugin{"plugin"}, "OnError", func() { panic("boom") })
+	})
+}
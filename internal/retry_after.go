@@ -0,0 +1,69 @@
+package internal
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// unixTimestampThreshold separates delta-seconds from unix timestamps in X-RateLimit-Reset,
+// as servers are inconsistent about which one they send.
+const unixTimestampThreshold = 1_000_000_000
+
+// maxSeconds is the largest number of seconds a time.Duration can hold
+const maxSeconds = math.MaxInt64 / int64(time.Second)
+
+// RetryAfter returns the wait requested by a throttled (429) or unavailable (503) response.
+// Retry-After (delta-seconds or HTTP-date) takes precedence over the RateLimit-Reset and X-RateLimit-Reset headers.
+func RetryAfter(response *http.Response, now time.Time) (time.Duration, bool) {
+	if response == nil ||
+		(response.StatusCode != http.StatusTooManyRequests && response.StatusCode != http.StatusServiceUnavailable) {
+		return 0, false
+	}
+
+	if v := response.Header.Get("Retry-After"); v != "" {
+		if seconds, ok := parseSeconds(v); ok {
+			return fromSeconds(seconds), true
+		}
+		if at, err := http.ParseTime(v); err == nil {
+			return nonNegative(at.Sub(now)), true
+		}
+	}
+
+	if seconds, ok := parseSeconds(response.Header.Get("RateLimit-Reset")); ok {
+		return fromSeconds(seconds), true
+	}
+
+	if seconds, ok := parseSeconds(response.Header.Get("X-RateLimit-Reset")); ok {
+		if seconds >= unixTimestampThreshold {
+			return nonNegative(time.Unix(seconds, 0).Sub(now)), true
+		}
+		return fromSeconds(seconds), true
+	}
+
+	return 0, false
+}
+
+func parseSeconds(v string) (int64, bool) {
+	seconds, err := strconv.ParseInt(strings.TrimSpace(v), 10, 64)
+	if err != nil || seconds < 0 {
+		return 0, false
+	}
+
+	return seconds, true
+}
+
+// fromSeconds converts delta-seconds to a duration, saturating instead of overflowing for oversized values
+func fromSeconds(seconds int64) time.Duration {
+	return nonNegative(time.Duration(min(seconds, maxSeconds)) * time.Second)
+}
+
+func nonNegative(d time.Duration) time.Duration {
+	if d < 0 {
+		return 0
+	}
+
+	return d
+}
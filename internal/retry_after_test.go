@@ -0,0 +1,59 @@
+package internal_test
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gojek/heimdall/v8/internal"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetryAfter(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2024, time.March, 1, 10, 0, 0, 0, time.UTC)
+	maxWait := time.Duration(math.MaxInt64/int64(time.Second)) * time.Second
+
+	tests := []struct {
+		name       string
+		statusCode int
+		header     http.Header
+		wait       time.Duration
+		ok         bool
+	}{
+		{"nil header", http.StatusTooManyRequests, http.Header{}, 0, false},
+		{"delta seconds", http.StatusTooManyRequests, http.Header{"Retry-After": {"3"}}, 3 * time.Second, true},
+		{"delta seconds on 503", http.StatusServiceUnavailable, http.Header{"Retry-After": {" 2 "}}, 2 * time.Second, true},
+		{"http date", http.StatusTooManyRequests, http.Header{"Retry-After": {now.Add(5 * time.Second).Format(http.TimeFormat)}}, 5 * time.Second, true},
+		{"http date in the past", http.StatusTooManyRequests, http.Header{"Retry-After": {now.Add(-5 * time.Second).Format(http.TimeFormat)}}, 0, true},
+		{"invalid value", http.StatusTooManyRequests, http.Header{"Retry-After": {"soon"}}, 0, false},
+		{"negative value", http.StatusTooManyRequests, http.Header{"Retry-After": {"-1"}}, 0, false},
+		{"ignored for other codes", http.StatusInternalServerError, http.Header{"Retry-After": {"3"}}, 0, false},
+		{"ratelimit reset", http.StatusTooManyRequests, http.Header{"Ratelimit-Reset": {"7"}}, 7 * time.Second, true},
+		{"x-ratelimit-reset delta", http.StatusTooManyRequests, http.Header{"X-Ratelimit-Reset": {"4"}}, 4 * time.Second, true},
+		{"x-ratelimit-reset timestamp", http.StatusTooManyRequests, http.Header{"X-Ratelimit-Reset": {strconv.FormatInt(now.Add(9*time.Second).Unix(), 10)}}, 9 * time.Second, true},
+		{"oversized delta seconds", http.StatusTooManyRequests, http.Header{"Retry-After": {"10000000000"}}, maxWait, true},
+		{"oversized ratelimit reset", http.StatusTooManyRequests, http.Header{"Ratelimit-Reset": {"10000000000"}}, maxWait, true},
+		{"retry-after takes precedence", http.StatusTooManyRequests, http.Header{"Retry-After": {"1"}, "Ratelimit-Reset": {"7"}}, time.Second, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			wait, ok := internal.RetryAfter(&http.Response{StatusCode: tt.statusCode, Header: tt.header}, now)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.wait, wait)
+		})
+	}
+}
+
+func TestRetryAfterNilResponse(t *testing.T) {
+	t.Parallel()
+
+	_, ok := internal.RetryAfter(nil, time.Now())
+	assert.False(t, ok)
+}
@@ -5,12 +5,12 @@ import (
 	"time"
 )
 
-// SleepInterruptible sleeps until either the timer triggers or context is cancelled
-func SleepInterruptible(ctx context.Context, d time.Duration) error {
+// SleepInterruptible sleeps until either the timer returned by after triggers or context is cancelled
+func SleepInterruptible(ctx context.Context, after func(time.Duration) <-chan time.Time, d time.Duration) error {
 	select {
 	case <-ctx.Done():
 		return ctx.Err()
-	case <-time.After(d):
+	case <-after(d):
 	}
 	return nil
 }
@@ -17,7 +17,7 @@ func TestSleepInterruptible_CancelledContext(t *testing.T) {
 	// Cancel the context immediately
 	cancel()
 
-	err := internal.SleepInterruptible(ctx, 10*time.Second) // Long duration to ensure cancellation is what stops it
+	err := internal.SleepInterruptible(ctx, time.After, 10*time.Second) // Long duration to ensure cancellation is what stops it
 	assert.Error(t, err)
 	assert.Equal(t, context.Canceled, err)
 }
@@ -28,9 +28,26 @@ func TestSleepInterruptible_CompletesWithoutCancel(t *testing.T) {
 	ctx := context.Background()
 	start := time.Now()
 
-	err := internal.SleepInterruptible(ctx, 50*time.Millisecond) // Short sleep time
+	err := internal.SleepInterruptible(ctx, time.After, 50*time.Millisecond) // Short sleep time
 	elapsed := time.Since(start)
 
 	assert.NoError(t, err)
 	assert.True(t, elapsed.Milliseconds() >= int64(50), "Sleep duration should be at least 50ms") // Ensure it slept approximately 50ms
 }
+
+func TestSleepInterruptible_WaitsForTheGivenTimer(t *testing.T) {
+	t.Parallel()
+
+	timer := make(chan time.Time, 1)
+	var requested time.Duration
+	after := func(d time.Duration) <-chan time.Time {
+		requested = d
+		return timer
+	}
+
+	timer <- time.Now()
+	err := internal.SleepInterruptible(context.Background(), after, time.Hour)
+
+	assert.NoError(t, err)
+	assert.Equal(t, time.Hour, requested)
+}
@@ -0,0 +1,43 @@
+package heimdall
+
+import "net/http"
+
+// Middleware wraps a Doer, e.g. to rewrite requests, return synthetic responses or wrap errors
+type Middleware func(next Doer) Doer
+
+// DoerFunc is an adapter to allow the use of ordinary functions as a Doer
+type DoerFunc func(*http.Request) (*http.Response, error)
+
+// Do calls f(req)
+func (f DoerFunc) Do(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// Chain returns a middleware applying the given middlewares in order, the first one being the outermost
+func Chain(middlewares ...Middleware) Middleware {
+	return func(next Doer) Doer {
+		for i := len(middlewares) - 1; i >= 0; i-- {
+			next = middlewares[i](next)
+		}
+		return next
+	}
+}
+
+// PluginMiddleware adapts a plugin to a middleware, reporting every request going through it to the plugin.
+// The plugin is given a copy of the request, so replacing it in OnRequestStart leaves the outer middlewares' untouched.
+func PluginMiddleware(p Plugin) Middleware {
+	return func(next Doer) Doer {
+		return DoerFunc(func(req *http.Request) (*http.Response, error) {
+			req = req.WithContext(req.Context())
+			p.OnRequestStart(req)
+			response, err := next.Do(req)
+			if err != nil {
+				p.OnError(req, err)
+				return response, err
+			}
+			p.OnRequestEnd(req, response)
+
+			return response, nil
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package heimdall
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recordingPlugin struct {
+	calls []string
+}
+
+func (p *recordingPlugin) OnRequestStart(*http.Request) {
+	p.calls = append(p.calls, "start")
+}
+
+func (p *recordingPlugin) OnRequestEnd(_ *http.Request, response *http.Response) {
+	p.calls = append(p.calls, http.StatusText(response.StatusCode))
+}
+
+func (p *recordingPlugin) OnError(_ *http.Request, err error) {
+	p.calls = append(p.calls, err.Error())
+}
+
+func tagging(tag string, calls *[]string) Middleware {
+	return func(next Doer) Doer {
+		return DoerFunc(func(req *http.Request) (*http.Response, error) {
+			*calls = append(*calls, tag)
+			return next.Do(req)
+		})
+	}
+}
+
+func TestChain(t *testing.T) {
+	t.Parallel()
+
+	var calls []string
+	doer := Chain(tagging("first", &calls), tagging("second", &calls))(DoerFunc(func(*http.Request) (*http.Response, error) {
+		calls = append(calls, "doer")
+		return &http.Response{StatusCode: http.StatusOK}, nil
+	}))
+
+	response, err := doer.Do(&http.Request{})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.Equal(t, []string{"first", "second", "doer"}, calls)
+}
+
+func TestChainWithoutMiddlewares(t *testing.T) {
+	t.Parallel()
+
+	doer := DoerFunc(func(*http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusTeapot}, nil
+	})
+
+	response, err := Chain()(doer).Do(&http.Request{})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusTeapot, response.StatusCode)
+}
+
+func TestPluginMiddleware(t *testing.T) {
+	t.Parallel()
+
+	plugin := &recordingPlugin{}
+	ok := PluginMiddleware(plugin)(DoerFunc(func(*http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusOK}, nil
+	}))
+	failing := PluginMiddleware(plugin)(DoerFunc(func(*http.Request) (*http.Response, error) {
+		return nil, errors.New("boom")
+	}))
+
+	_, err := ok.Do(&http.Request{})
+	require.NoError(t, err)
+	_, err = failing.Do(&http.Request{})
+	require.EqualError(t, err, "boom")
+
+	assert.Equal(t, []string{"start", "OK", "start", "boom"}, plugin.calls)
+}
+
+type replacingPlugin struct{}
+
+func (replacingPlugin) OnRequestStart(req *http.Request) {
+	*req = *req.WithContext(ContextWithAttempt(req.Context(), 2))
+}
+
+func (replacingPlugin) OnRequestEnd(*http.Request, *http.Response) {}
+
+func (replacingPlugin) OnError(*http.Request, error) {}
+
+func TestPluginMiddlewareGivesThePluginACopy(t *testing.T) {
+	t.Parallel()
+
+	var seen int
+	doer := PluginMiddleware(replacingPlugin{})(DoerFunc(func(req *http.Request) (*http.Response, error) {
+		seen, _ = AttemptFromContext(req.Context())
+		return &http.Response{StatusCode: http.StatusOK}, nil
+	}))
+
+	req, err := http.NewRequest(http.MethodGet, "http://localhost", nil)
+	require.NoError(t, err)
+	_, err = doer.Do(req)
+	require.NoError(t, err)
+
+	assert.Equal(t, 2, seen, "the replaced request should be sent")
+	_, ok := AttemptFromContext(req.Context())
+	assert.False(t, ok, "the caller's request should be left untouched")
+}
@@ -2,12 +2,57 @@ package heimdall
 
 import (
 	"net/http"
+	"time"
 )
 
 // Plugin defines the interface that a Heimdall plugin must have
-// plugins can be added to a Heimdall client using the `AddPlugin` method
+// plugins can be added to a Heimdall client using the `AddPlugin` method.
+// Plugins may also implement CallPlugin, RetryPlugin, GiveUpPlugin, BudgetPlugin and CircuitPlugin to follow the
+// lifecycle of calls.
 type Plugin interface {
 	OnRequestStart(*http.Request)
 	OnRequestEnd(*http.Request, *http.Response)
 	OnError(*http.Request, error)
 }
+
+// CallPlugin is an optional plugin interface, notified when a call starts, before its first attempt, and when it
+// ends with the response and error returned to the caller after the given number of attempts.
+// Like OnRequestStart, OnCallStart may replace the request, e.g. its context, which every attempt then inherits.
+type CallPlugin interface {
+	OnCallStart(req *http.Request)
+	OnCallEnd(req *http.Request, res *http.Response, attempts int, err error)
+}
+
+// RetryPlugin is an optional plugin interface, notified before a call is retried.
+// attempt is the number of the attempt about to be made, 2 for the first retry, wait is the backoff before it
+// and cause is the attempt being retried.
+type RetryPlugin interface {
+	OnRetry(req *http.Request, attempt int, wait time.Duration, cause Attempt)
+}
+
+// GiveUpPlugin is an optional plugin interface, notified when a call fails after the given number of attempts.
+// err is the error returned by the call, or a RetryableStatusError if the call ended with a retryable response.
+type GiveUpPlugin interface {
+	OnGiveUp(req *http.Request, attempts int, err error)
+}
+
+// BudgetPlugin is an optional plugin interface, notified when a failed attempt isn't retried because
+// the retry error budget is exhausted.
+type BudgetPlugin interface {
+	OnBudgetExhausted(req *http.Request)
+}
+
+// CircuitPlugin is an optional plugin interface, notified when a call is rejected by an open circuit.
+type CircuitPlugin interface {
+	OnCircuitOpen(req *http.Request)
+}
+
+// PluginHandle is returned when registering a plugin, to unregister it later
+type PluginHandle interface {
+	// Remove unregisters the plugin, returning false if it was already removed
+	Remove() bool
+}
+
+// PluginPanicHandler is called with a plugin which panicked, the name of the hook which panicked,
+// e.g. "OnRequestEnd", and the recovered value.
+type PluginPanicHandler func(p Plugin, hook string, recovered any)
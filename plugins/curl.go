@@ -0,0 +1,161 @@
+package plugins
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/gojek/heimdall/v8"
+)
+
+const curlRequestBody ctxKey = "curl_request_body"
+
+type curlGenerator struct {
+	mu           sync.Mutex // serialises the commands of concurrent requests
+	out          io.Writer
+	callback     func(req *http.Request, command string)
+	statusFilter func(statusCode int) bool
+	maxBodySize  int
+	redaction    Redaction
+}
+
+// CurlGeneratorOption represents the curl generator options
+type CurlGeneratorOption func(*curlGenerator)
+
+// WithCurlCallback sends the generated commands to the callback instead of the writer
+func WithCurlCallback(callback func(req *http.Request, command string)) CurlGeneratorOption {
+	return func(cg *curlGenerator) {
+		cg.callback = callback
+	}
+}
+
+// WithCurlStatusFilter sets the filter selecting the responses whose requests are rendered as commands,
+// e.g. the 5xx ones. By default, only the requests failing without a response are.
+func WithCurlStatusFilter(filter func(statusCode int) bool) CurlGeneratorOption {
+	return func(cg *curlGenerator) {
+		cg.statusFilter = filter
+	}
+}
+
+// WithCurlMaxBodySize sets the maximum number of bytes of the request bodies put in the commands, 64KiB by default
+func WithCurlMaxBodySize(size int) CurlGeneratorOption {
+	return func(cg *curlGenerator) {
+		cg.maxBodySize = max(size, 0)
+	}
+}
+
+// WithCurlRedaction sets the rules masking secrets in the commands, DefaultRedaction() by default
+func WithCurlRedaction(redaction Redaction) CurlGeneratorOption {
+	return func(cg *curlGenerator) {
+		cg.redaction = redaction
+	}
+}
+
+// NewCurlGenerator returns a new instance of a Heimdall plugin rendering failing requests as curl commands,
+// to reproduce them by hand. Headers and bodies are redacted, and request bodies are restored once read.
+// If given as nil, `out` takes the default value of `os.StdErr`
+func NewCurlGenerator(out io.Writer, opts ...CurlGeneratorOption) heimdall.Plugin {
+	if out == nil {
+		out = os.Stderr
+	}
+
+	cg := &curlGenerator{
+		out:         out,
+		maxBodySize: defaultMaxDumpBodySize,
+		redaction:   DefaultRedaction(),
+	}
+	for _, opt := range opts {
+		opt(cg)
+	}
+	return cg
+}
+
+func (cg *curlGenerator) OnRequestStart(req *http.Request) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return
+	}
+
+	var body capturedBody
+	body, req.Body = captureBody(req.Body, cg.maxBodySize)
+
+	ctx := context.WithValue(req.Context(), curlRequestBody, body)
+	*req = *(req.WithContext(ctx))
+}
+
+func (cg *curlGenerator) OnRequestEnd(req *http.Request, res *http.Response) {
+	if cg.statusFilter != nil && cg.statusFilter(res.StatusCode) {
+		cg.emit(req)
+	}
+}
+
+func (cg *curlGenerator) OnError(req *http.Request, _ error) {
+	cg.emit(req)
+}
+
+func (cg *curlGenerator) emit(req *http.Request) {
+	command := cg.command(req)
+	if cg.callback != nil {
+		cg.callback(req, command)
+		return
+	}
+
+	cg.mu.Lock()
+	defer cg.mu.Unlock()
+
+	_, _ = io.WriteString(cg.out, command+"\n")
+}
+
+// command renders the request as a curl command, one option per line
+func (cg *curlGenerator) command(req *http.Request) string {
+	args := []string{"curl"}
+	switch req.Method {
+	case "", http.MethodGet:
+	case http.MethodHead:
+		args = append(args, "--head")
+	default:
+		args = append(args, "-X "+shellQuote(req.Method))
+	}
+	args = append(args, shellQuote(cg.redaction.RedactString(req.URL.Redacted())))
+
+	header := cg.redaction.RedactHeader(req.Header)
+	if header == nil {
+		header = http.Header{}
+	}
+	if req.Host != "" && req.Host != req.URL.Host {
+		header.Set("Host", req.Host)
+	}
+	names := make([]string, 0, len(header))
+	for name := range header {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		for _, value := range header[name] {
+			args = append(args, "-H "+shellQuote(name+": "+value))
+		}
+	}
+
+	var truncated string
+	if body, ok := req.Context().Value(curlRequestBody).(capturedBody); ok && len(body.data) > 0 {
+		data := body.data
+		if !body.truncated {
+			data = cg.redaction.RedactBody(data)
+		} else {
+			data = cg.redaction.RedactTruncatedBody(data)
+			truncated = fmt.Sprintf("# request body truncated to %d bytes\n", len(body.data))
+		}
+		args = append(args, "--data-binary "+shellQuote(string(data)))
+	}
+
+	return truncated + strings.Join(args, " \\\n  ")
+}
+
+// shellQuote quotes the string for POSIX shells
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,197 @@
+package plugins
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"slices"
+	"sync"
+
+	"github.com/gojek/heimdall/v8"
+)
+
+const (
+	dumpedRequest ctxKey = "dumped_request"
+
+	defaultMaxDumpBodySize = 64 << 10
+)
+
+// capturedBody is the beginning of a body, up to the maximum dumped size
+type capturedBody struct {
+	data      []byte
+	truncated bool
+}
+
+type requestDump struct {
+	header http.Header
+	body   capturedBody
+}
+
+type dumper struct {
+	mu          sync.Mutex // serialises dumps of concurrent requests
+	out         io.Writer
+	maxBodySize int
+	redaction   Redaction
+}
+
+// DumperOption represents the dumper options
+type DumperOption func(*dumper)
+
+// WithDumpMaxBodySize sets the maximum number of bytes dumped of each body, 64KiB by default
+func WithDumpMaxBodySize(size int) DumperOption {
+	return func(d *dumper) {
+		d.maxBodySize = max(size, 0)
+	}
+}
+
+// WithDumpRedaction sets the rules masking secrets in the dumps, DefaultRedaction() by default
+func WithDumpRedaction(redaction Redaction) DumperOption {
+	return func(d *dumper) {
+		d.redaction = redaction
+	}
+}
+
+// NewDumper returns a new instance of a Heimdall plugin dumping every request along with its response or error,
+// for debugging. Headers and bodies are redacted, and bodies are capped in size. The bodies read for the dump are
+// restored, so the caller still gets them intact.
+// If given as nil, `out` takes the default value of `os.StdErr`
+func NewDumper(out io.Writer, opts ...DumperOption) heimdall.Plugin {
+	if out == nil {
+		out = os.Stderr
+	}
+
+	d := &dumper{
+		out:         out,
+		maxBodySize: defaultMaxDumpBodySize,
+		redaction:   DefaultRedaction(),
+	}
+	for _, opt := range opts {
+		opt(d)
+	}
+	return d
+}
+
+func (d *dumper) OnRequestStart(req *http.Request) {
+	dump := requestDump{header: req.Header.Clone()}
+	if req.Body != nil && req.Body != http.NoBody {
+		dump.body, req.Body = captureBody(req.Body, d.maxBodySize)
+	}
+
+	ctx := context.WithValue(req.Context(), dumpedRequest, dump)
+	*req = *(req.WithContext(ctx))
+}
+
+func (d *dumper) OnRequestEnd(req *http.Request, res *http.Response) {
+	var body capturedBody
+	if res.Body != nil && res.Body != http.NoBody {
+		body, res.Body = captureBody(res.Body, d.maxBodySize)
+	}
+
+	var buf bytes.Buffer
+	d.writeRequest(&buf, req)
+	fmt.Fprintf(&buf, "< %d %s\n", res.StatusCode, http.StatusText(res.StatusCode))
+	d.writeHeaderAndBody(&buf, "< ", res.Header, body)
+	d.write(buf.Bytes())
+}
+
+func (d *dumper) OnError(req *http.Request, err error) {
+	var buf bytes.Buffer
+	d.writeRequest(&buf, req)
+	fmt.Fprintf(&buf, "! %s\n", d.redaction.RedactString(err.Error()))
+	d.write(buf.Bytes())
+}
+
+func (d *dumper) writeRequest(buf *bytes.Buffer, req *http.Request) {
+	fmt.Fprintf(buf, "> %s %s", req.Method, d.redaction.RedactString(req.URL.Redacted()))
+	if attempt, ok := heimdall.AttemptFromContext(req.Context()); ok {
+		fmt.Fprintf(buf, " (attempt %d)", attempt)
+	}
+	buf.WriteByte('\n')
+
+	dump, _ := req.Context().Value(dumpedRequest).(requestDump)
+	if dump.header == nil {
+		dump.header = req.Header
+	}
+	d.writeHeaderAndBody(buf, "> ", dump.header, dump.body)
+}
+
+func (d *dumper) writeHeaderAndBody(buf *bytes.Buffer, prefix string, header http.Header, body capturedBody) {
+	header = d.redaction.RedactHeader(header)
+	names := make([]string, 0, len(header))
+	for name := range header {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	for _, name := range names {
+		for _, value := range header[name] {
+			fmt.Fprintf(buf, "%s%s: %s\n", prefix, name, value)
+		}
+	}
+
+	if len(body.data) == 0 {
+		return
+	}
+
+	data := body.data
+	if !body.truncated {
+		data = d.redaction.RedactBody(data)
+	} else {
+		data = d.redaction.RedactTruncatedBody(data)
+	}
+
+	fmt.Fprintf(buf, "%s\n", prefix)
+	for line := range bytes.Lines(data) {
+		fmt.Fprintf(buf, "%s%s", prefix, line)
+	}
+	if !bytes.HasSuffix(data, []byte("\n")) {
+		buf.WriteByte('\n')
+	}
+	if body.truncated {
+		fmt.Fprintf(buf, "%s[truncated to %d bytes]\n", prefix, len(body.data))
+	}
+}
+
+func (d *dumper) write(dump []byte) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	_, _ = d.out.Write(dump)
+}
+
+// captureBody reads the beginning of a body, up to maxSize bytes, and returns it along with a body replaying it
+// before the rest of the original body.
+func captureBody(body io.ReadCloser, maxSize int) (capturedBody, io.ReadCloser) {
+	data, err := io.ReadAll(io.LimitReader(body, int64(maxSize)+1))
+	captured := capturedBody{data: data}
+	if len(data) > maxSize {
+		captured = capturedBody{data: data[:maxSize], truncated: true}
+	}
+
+	var rest io.Reader = body
+	if err != nil {
+		rest = &errReader{err: err} // the caller gets the read error once done with the captured bytes
+	}
+
+	return captured, &replayedBody{Reader: io.MultiReader(bytes.NewReader(data), rest), closer: body}
+}
+
+type replayedBody struct {
+	io.Reader
+	closer io.Closer
+}
+
+func (b *replayedBody) Close() error {
+	return b.closer.Close()
+}
+
+type errReader struct {
+	err error
+}
+
+func (r *errReader) Read([]byte) (int, error) {
+	return 0, r.err
+}
@@ -0,0 +1,149 @@
+package plugins
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gojek/heimdall/v8/httpclient"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDumperDumpsRequestAndResponse(t *testing.T) {
+	t.Parallel()
+
+	var received string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received = string(body)
+		w.Header().Set("Set-Cookie", "session=abc")
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"token":"s3cret","id":1}`))
+	}))
+	defer server.Close()
+
+	redaction := DefaultRedaction()
+	redaction.JSONPaths = []string{"token", "card.number"}
+
+	var out bytes.Buffer
+	client := httpclient.NewClient()
+	client.AddPlugin(NewDumper(&out, WithDumpRedaction(redaction)))
+
+	requestBody := `{"card":{"number":"4111111111111111","cvv":"123"}}`
+	response, err := client.Post(server.URL+"/cards", strings.NewReader(requestBody), http.Header{
+		"Authorization": {"Bearer abc"},
+		"Content-Type":  {"application/json"},
+	})
+	require.NoError(t, err)
+
+	body, err := io.ReadAll(response.Body)
+	require.NoError(t, err)
+	assert.Equal(t, `{"token":"s3cret","id":1}`, string(body), "the caller gets the response body intact")
+	assert.Equal(t, requestBody, received, "the server gets the request body intact")
+
+	dump := out.String()
+	assert.Contains(t, dump, "> POST "+server.URL+"/cards (attempt 1)\n")
+	assert.Contains(t, dump, "> Authorization: [REDACTED]\n")
+	assert.Contains(t, dump, "> Content-Type: application/json\n")
+	assert.Contains(t, dump, `> {"card":{"cvv":"123","number":"[REDACTED]"}}`)
+	assert.Contains(t, dump, "< 201 Created\n")
+	assert.Contains(t, dump, "< Set-Cookie: [REDACTED]\n")
+	assert.Contains(t, dump, `< {"id":1,"token":"[REDACTED]"}`)
+	assert.NotContains(t, dump, "s3cret")
+	assert.NotContains(t, dump, "4111111111111111")
+}
+
+func TestDumperTruncatesBodies(t *testing.T) {
+	t.Parallel()
+
+	responseBody := `{"password":"hunter2","padding":"` + strings.Repeat("x", 100) + `"}`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(responseBody))
+	}))
+	defer server.Close()
+
+	var out bytes.Buffer
+	client := httpclient.NewClient()
+	client.AddPlugin(NewDumper(&out,
+		WithDumpMaxBodySize(16),
+		WithDumpRedaction(Redaction{JSONPaths: []string{"password"}}),
+	))
+
+	response, err := client.Get(server.URL, nil)
+	require.NoError(t, err)
+
+	body, err := io.ReadAll(response.Body)
+	require.NoError(t, err)
+	assert.Equal(t, responseBody, string(body), "the caller gets the whole body")
+
+	dump := out.String()
+	assert.Contains(t, dump, "< [REDACTED]\n< [truncated to 16 bytes]\n")
+	assert.NotContains(t, dump, "hunter2")
+}
+
+func TestDumperDumpsErrors(t *testing.T) {
+	t.Parallel()
+
+	var out bytes.Buffer
+	client := httpclient.NewClient(httpclient.WithHTTPTimeout(time.Second))
+	client.AddPlugin(NewDumper(&out))
+
+	_, err := client.Get("http://localhost:0/path", http.Header{"Cookie": {"a=b"}})
+	require.Error(t, err)
+
+	dump := out.String()
+	assert.Contains(t, dump, "> GET http://localhost:0/path (attempt 1)\n")
+	assert.Contains(t, dump, "> Cookie: [REDACTED]\n")
+	assert.Contains(t, dump, "! ")
+}
+
+func TestCaptureBodyRestoresTheBody(t *testing.T) {
+	t.Parallel()
+
+	original := &closeRecorder{Reader: strings.NewReader("0123456789")}
+
+	captured, body := captureBody(original, 4)
+	assert.Equal(t, []byte("0123"), captured.data)
+	assert.True(t, captured.truncated)
+
+	data, err := io.ReadAll(body)
+	require.NoError(t, err)
+	assert.Equal(t, "0123456789", string(data))
+
+	require.NoError(t, body.Close())
+	assert.True(t, original.closed)
+
+	captured, _ = captureBody(io.NopCloser(strings.NewReader("0123")), 4)
+	assert.Equal(t, []byte("0123"), captured.data)
+	assert.False(t, captured.truncated)
+}
+
+func TestCaptureBodyReplaysReadErrors(t *testing.T) {
+	t.Parallel()
+
+	readErr := errors.New("connection reset")
+	original := io.NopCloser(io.MultiReader(strings.NewReader("01"), &errReader{err: readErr}))
+
+	captured, body := captureBody(original, 4)
+	assert.Equal(t, []byte("01"), captured.data)
+
+	data, err := io.ReadAll(body)
+	assert.ErrorIs(t, err, readErr)
+	assert.Equal(t, "01", string(data))
+}
+
+type closeRecorder struct {
+	io.Reader
+	closed bool
+}
+
+func (c *closeRecorder) Close() error {
+	c.closed = true
+	return nil
+}
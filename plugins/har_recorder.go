@@ -0,0 +1,472 @@
+package plugins
+
+import (
+	"cmp"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptrace"
+	"os"
+	"slices"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gojek/heimdall/v8"
+)
+
+const (
+	harAttemptKey ctxKey = "har_attempt"
+
+	defaultMaxHAREntries = 1000
+)
+
+// HARRecorder is a Heimdall plugin recording every attempt into a HAR 1.2 document, e.g. to load into browser
+// devtools. The attempts of a call are grouped in a page. Headers and bodies are redacted, and bodies are capped
+// in size. The bodies read for the record are restored, so the caller still gets them intact. Once the maximum
+// number of entries is reached, the oldest ones are dropped.
+type HARRecorder struct {
+	clock       heimdall.Clock
+	maxBodySize int
+	maxEntries  int
+	redaction   Redaction
+
+	mu      sync.Mutex
+	pages   map[string]*harPage
+	entries []HAREntry
+	dropped int
+}
+
+// harPage is the page of a call, along with the number of its entries recorded
+type harPage struct {
+	HARPage
+	entries int
+}
+
+// HARRecorderOption represents the HAR recorder options
+type HARRecorderOption func(*HARRecorder)
+
+// WithHARMaxBodySize sets the maximum number of bytes recorded of each body, 64KiB by default
+func WithHARMaxBodySize(size int) HARRecorderOption {
+	return func(r *HARRecorder) {
+		r.maxBodySize = max(size, 0)
+	}
+}
+
+// WithHARMaxEntries sets the maximum number of entries kept, 1000 by default, the oldest ones being dropped
+// first, or no maximum if zero
+func WithHARMaxEntries(entries int) HARRecorderOption {
+	return func(r *HARRecorder) {
+		r.maxEntries = max(entries, 0)
+	}
+}
+
+// WithHARRedaction sets the rules masking secrets in the recorded requests and responses,
+// DefaultRedaction() by default
+func WithHARRedaction(redaction Redaction) HARRecorderOption {
+	return func(r *HARRecorder) {
+		r.redaction = redaction
+	}
+}
+
+// WithHARClock sets the clock used to time attempts
+func WithHARClock(clock heimdall.Clock) HARRecorderOption {
+	return func(r *HARRecorder) {
+		r.clock = clock
+	}
+}
+
+// NewHARRecorder returns a new HAR recorder, to add to a client as a plugin
+func NewHARRecorder(opts ...HARRecorderOption) *HARRecorder {
+	r := &HARRecorder{
+		clock:       heimdall.NewSystemClock(),
+		maxBodySize: defaultMaxDumpBodySize,
+		maxEntries:  defaultMaxHAREntries,
+		redaction:   DefaultRedaction(),
+		pages:       map[string]*harPage{},
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// HAR returns the document recorded so far
+func (r *HARRecorder) HAR() HAR {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.har()
+}
+
+// WriteTo writes the document recorded so far as JSON
+func (r *HARRecorder) WriteTo(w io.Writer) (int64, error) {
+	data, err := json.MarshalIndent(r.HAR(), "", "  ")
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := w.Write(data)
+	return int64(n), err
+}
+
+// Flush writes the document recorded so far to the file at path, then starts a new one.
+// Nothing is discarded if the file can't be written.
+func (r *HARRecorder) Flush(path string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	data, err := json.MarshalIndent(r.har(), "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		return fmt.Errorf("HAR flush failed: %w", err)
+	}
+
+	r.reset()
+	return nil
+}
+
+// Reset discards the document recorded so far
+func (r *HARRecorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.reset()
+}
+
+func (r *HARRecorder) OnRequestStart(req *http.Request) {
+	attempt := &harAttempt{clock: r.clock, start: r.clock.Now(), header: req.Header.Clone()}
+	if req.Body != nil && req.Body != http.NoBody {
+		attempt.body, req.Body = captureBody(req.Body, r.maxBodySize)
+	}
+
+	ctx := context.WithValue(req.Context(), harAttemptKey, attempt)
+	ctx = httptrace.WithClientTrace(ctx, attempt.trace())
+	*req = *(req.WithContext(ctx))
+}
+
+func (r *HARRecorder) OnRequestEnd(req *http.Request, res *http.Response) {
+	var body capturedBody
+	if res.Body != nil && res.Body != http.NoBody {
+		body, res.Body = captureBody(res.Body, r.maxBodySize)
+	}
+
+	response := HARResponse{
+		Status:      res.StatusCode,
+		StatusText:  http.StatusText(res.StatusCode),
+		HTTPVersion: httpVersion(res.Proto),
+		Cookies:     r.cookies(res.Cookies(), "Set-Cookie"),
+		Headers:     r.headers(res.Header),
+		Content:     r.content(res.Header.Get("Content-Type"), res.ContentLength, body),
+		RedirectURL: res.Header.Get("Location"),
+		HeadersSize: -1,
+		BodySize:    bodySize(res.ContentLength, body),
+	}
+
+	r.record(req, response)
+}
+
+func (r *HARRecorder) OnError(req *http.Request, err error) {
+	r.record(req, HARResponse{
+		HTTPVersion: httpVersion(""),
+		Cookies:     []HARCookie{},
+		Headers:     []HARNameValue{},
+		HeadersSize: -1,
+		BodySize:    -1,
+		Error:       r.redaction.RedactString(err.Error()),
+	})
+}
+
+// record adds the entry of the attempt, and the page of its call if it's the first attempt recorded
+func (r *HARRecorder) record(req *http.Request, response HARResponse) {
+	end := r.clock.Now()
+	attempt, ok := req.Context().Value(harAttemptKey).(*harAttempt)
+	if !ok {
+		attempt = &harAttempt{start: end, header: req.Header}
+	}
+
+	entry := HAREntry{
+		StartedDateTime: attempt.start,
+		Time:            milliseconds(end.Sub(attempt.start)),
+		Request:         r.request(req, attempt),
+		Response:        response,
+		Timings:         attempt.timings(end),
+	}
+	if n, ok := heimdall.AttemptFromContext(req.Context()); ok {
+		entry.Comment = fmt.Sprintf("attempt %d", n)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if callID, ok := heimdall.CallIDFromContext(req.Context()); ok {
+		entry.PageRef = fmt.Sprintf("call_%d", callID)
+		page, ok := r.pages[entry.PageRef]
+		if !ok {
+			page = &harPage{HARPage: HARPage{
+				StartedDateTime: attempt.start,
+				ID:              entry.PageRef,
+				Title:           req.Method + " " + entry.Request.URL,
+				PageTimings:     HARPageTimings{OnContentLoad: -1, OnLoad: -1},
+			}}
+			r.pages[entry.PageRef] = page
+		}
+		if attempt.start.Before(page.StartedDateTime) {
+			page.StartedDateTime = attempt.start // e.g. for hedged attempts ending out of order
+		}
+		page.entries++
+	}
+
+	r.entries = append(r.entries, entry)
+	if r.maxEntries > 0 && len(r.entries) > r.maxEntries {
+		r.drop(len(r.entries) - r.maxEntries)
+	}
+}
+
+// drop discards the oldest entries recorded, along with the pages left without entries
+func (r *HARRecorder) drop(n int) {
+	for _, entry := range r.entries[:n] {
+		if page, ok := r.pages[entry.PageRef]; ok {
+			if page.entries--; page.entries == 0 {
+				delete(r.pages, entry.PageRef)
+			}
+		}
+	}
+
+	r.entries = slices.Delete(r.entries, 0, n)
+	r.dropped += n
+}
+
+func (r *HARRecorder) request(req *http.Request, attempt *harAttempt) HARRequest {
+	request := HARRequest{
+		Method:      req.Method,
+		URL:         r.redaction.RedactString(req.URL.Redacted()),
+		HTTPVersion: httpVersion(req.Proto),
+		Cookies:     r.cookies((&http.Request{Header: attempt.header}).Cookies(), "Cookie"),
+		Headers:     r.headers(attempt.header),
+		QueryString: []HARNameValue{},
+		HeadersSize: -1,
+		BodySize:    bodySize(req.ContentLength, attempt.body),
+	}
+
+	for name, values := range req.URL.Query() {
+		for _, value := range values {
+			request.QueryString = append(request.QueryString, HARNameValue{Name: name, Value: r.redaction.RedactString(value)})
+		}
+	}
+	slices.SortStableFunc(request.QueryString, compareNames)
+
+	if len(attempt.body.data) > 0 {
+		content := r.content(attempt.header.Get("Content-Type"), req.ContentLength, attempt.body)
+		request.PostData = &HARPostData{MimeType: content.MimeType, Text: content.Text, Comment: content.Comment}
+		if content.Encoding != "" {
+			request.PostData.Text = ""
+			request.PostData.Comment = "binary body omitted"
+		}
+	}
+
+	return request
+}
+
+func (r *HARRecorder) headers(header http.Header) []HARNameValue {
+	headers := []HARNameValue{}
+	for name, values := range r.redaction.RedactHeader(header) {
+		for _, value := range values {
+			headers = append(headers, HARNameValue{Name: name, Value: value})
+		}
+	}
+	slices.SortStableFunc(headers, compareNames)
+
+	return headers
+}
+
+// cookies returns the cookies, with their values masked if the header they come from is redacted
+func (r *HARRecorder) cookies(cookies []*http.Cookie, header string) []HARCookie {
+	masked := r.redaction.isRedactedHeader(header)
+	result := make([]HARCookie, 0, len(cookies))
+	for _, cookie := range cookies {
+		value := cookie.Value
+		if masked {
+			value = redacted
+		}
+		result = append(result, HARCookie{Name: cookie.Name, Value: value})
+	}
+
+	return result
+}
+
+func (r *HARRecorder) content(mimeType string, contentLength int64, body capturedBody) HARContent {
+	content := HARContent{Size: int64(len(body.data)), MimeType: mimeType}
+	if contentLength > 0 {
+		content.Size = contentLength
+	}
+
+	data := body.data
+	if body.truncated {
+		data = r.redaction.RedactTruncatedBody(data)
+		content.Comment = fmt.Sprintf("truncated to %d bytes", len(body.data))
+	} else {
+		data = r.redaction.RedactBody(data)
+	}
+
+	if utf8.Valid(data) {
+		content.Text = string(data)
+	} else {
+		content.Text = base64.StdEncoding.EncodeToString(data)
+		content.Encoding = "base64"
+	}
+
+	return content
+}
+
+func (r *HARRecorder) har() HAR {
+	entries := slices.Clone(r.entries)
+	slices.SortStableFunc(entries, func(a, b HAREntry) int {
+		return a.StartedDateTime.Compare(b.StartedDateTime)
+	})
+
+	pages := make([]HARPage, 0, len(r.pages))
+	for _, page := range r.pages {
+		pages = append(pages, page.HARPage)
+	}
+	slices.SortFunc(pages, func(a, b HARPage) int {
+		return cmp.Or(a.StartedDateTime.Compare(b.StartedDateTime), cmp.Compare(a.ID, b.ID))
+	})
+
+	if entries == nil {
+		entries = []HAREntry{}
+	}
+
+	log := HARLog{
+		Version: "1.2",
+		Creator: HARCreator{Name: "heimdall", Version: "8"},
+		Pages:   pages,
+		Entries: entries,
+	}
+	if r.dropped > 0 {
+		log.Comment = fmt.Sprintf("%d older entries dropped", r.dropped)
+	}
+
+	return HAR{Log: log}
+}
+
+func (r *HARRecorder) reset() {
+	r.pages = map[string]*harPage{}
+	r.entries = nil
+	r.dropped = 0
+}
+
+// harAttempt collects the timings of an attempt from the events of its client trace
+type harAttempt struct {
+	clock  heimdall.Clock
+	start  time.Time
+	header http.Header
+	body   capturedBody
+
+	mu                        sync.Mutex // trace events may come from other goroutines, e.g. while dialing
+	dnsStart, dnsDone         time.Time
+	connectStart, connectDone time.Time
+	tlsStart, tlsDone         time.Time
+	gotConn, wroteRequest     time.Time
+	firstByte                 time.Time
+}
+
+func (a *harAttempt) trace() *httptrace.ClientTrace {
+	return &httptrace.ClientTrace{
+		DNSStart:             func(httptrace.DNSStartInfo) { a.mark(&a.dnsStart) },
+		DNSDone:              func(httptrace.DNSDoneInfo) { a.mark(&a.dnsDone) },
+		ConnectStart:         func(string, string) { a.mark(&a.connectStart) },
+		ConnectDone:          func(string, string, error) { a.mark(&a.connectDone) },
+		TLSHandshakeStart:    func() { a.mark(&a.tlsStart) },
+		TLSHandshakeDone:     func(tls.ConnectionState, error) { a.mark(&a.tlsDone) },
+		GotConn:              func(httptrace.GotConnInfo) { a.mark(&a.gotConn) },
+		WroteRequest:         func(httptrace.WroteRequestInfo) { a.mark(&a.wroteRequest) },
+		GotFirstResponseByte: func() { a.mark(&a.firstByte) },
+	}
+}
+
+// mark records the time of the first occurrence of an event
+func (a *harAttempt) mark(event *time.Time) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if event.IsZero() {
+		*event = a.clock.Now()
+	}
+}
+
+// timings splits the attempt into its phases. The time not accounted for by the trace, e.g. with a Doer
+// which isn't an http.Client, counts as waiting for the response.
+func (a *harAttempt) timings(end time.Time) HARTimings {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	connectDone := a.connectDone
+	if !a.tlsDone.IsZero() {
+		connectDone = a.tlsDone // the connect phase includes the TLS handshake
+	}
+
+	timings := HARTimings{
+		Blocked: -1,
+		DNS:     phase(a.dnsStart, a.dnsDone),
+		Connect: phase(a.connectStart, connectDone),
+		SSL:     phase(a.tlsStart, a.tlsDone),
+	}
+	if !a.gotConn.IsZero() {
+		timings.Blocked = max(phase(a.start, a.gotConn)-max(timings.DNS, 0)-max(timings.Connect, 0), 0)
+		timings.Send = max(phase(a.gotConn, a.wroteRequest), 0)
+	}
+	if !a.firstByte.IsZero() {
+		timings.Receive = max(phase(a.firstByte, end), 0)
+	}
+
+	total := milliseconds(end.Sub(a.start))
+	accounted := max(timings.Blocked, 0) + max(timings.DNS, 0) + max(timings.Connect, 0) + timings.Send + timings.Receive
+	timings.Wait = max(total-accounted, 0)
+
+	return timings
+}
+
+// phase returns the duration between two events in milliseconds, or -1 if either didn't happen
+func phase(from, to time.Time) float64 {
+	if from.IsZero() || to.IsZero() || to.Before(from) {
+		return -1
+	}
+
+	return milliseconds(to.Sub(from))
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+// bodySize returns the size of a body, or -1 if it isn't known
+func bodySize(contentLength int64, body capturedBody) int64 {
+	switch {
+	case contentLength >= 0 && (contentLength > 0 || len(body.data) == 0):
+		return contentLength
+	case !body.truncated:
+		return int64(len(body.data))
+	default:
+		return -1
+	}
+}
+
+func httpVersion(proto string) string {
+	if proto == "" {
+		return "HTTP/1.1"
+	}
+
+	return proto
+}
+
+func compareNames(a, b HARNameValue) int {
+	return cmp.Compare(a.Name, b.Name)
+}
@@ -0,0 +1,261 @@
+package plugins
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gojek/heimdall/v8"
+	"github.com/gojek/heimdall/v8/httpclient"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHARRecorderGroupsTheAttemptsOfACall(t *testing.T) {
+	t.Parallel()
+
+	count := atomic.Int32{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		assert.Equal(t, `{"name":"jane"}`, string(body))
+
+		w.Header().Set("Content-Type", "application/json")
+		if count.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		http.SetCookie(w, &http.Cookie{Name: "session", Value: "abc"})
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"id":1}`))
+	}))
+	defer server.Close()
+
+	recorder := NewHARRecorder()
+	client := httpclient.NewClient(
+		httpclient.WithRetryCount(1),
+		httpclient.WithRetrier(heimdall.NewRetrier(heimdall.NewConstantBackoff(time.Millisecond, 0))),
+	)
+	client.AddPlugin(recorder)
+
+	response, err := client.Put(server.URL+"/users?b=2&a=1", strings.NewReader(`{"name":"jane"}`), http.Header{
+		"Authorization": {"Bearer abc"},
+		"Content-Type":  {"application/json"},
+	})
+	require.NoError(t, err)
+	body, err := io.ReadAll(response.Body)
+	require.NoError(t, err)
+	assert.Equal(t, `{"id":1}`, string(body), "the caller gets the response body intact")
+
+	log := recorder.HAR().Log
+	assert.Equal(t, "1.2", log.Version)
+	assert.Equal(t, "heimdall", log.Creator.Name)
+	require.Len(t, log.Pages, 1)
+	require.Len(t, log.Entries, 2)
+
+	page := log.Pages[0]
+	assert.True(t, strings.HasPrefix(page.ID, "call_"))
+	assert.Equal(t, "PUT "+server.URL+"/users?b=2&a=1", page.Title)
+	assert.Equal(t, log.Entries[0].StartedDateTime, page.StartedDateTime)
+
+	first, second := log.Entries[0], log.Entries[1]
+	assert.Equal(t, page.ID, first.PageRef)
+	assert.Equal(t, page.ID, second.PageRef)
+	assert.Equal(t, "attempt 1", first.Comment)
+	assert.Equal(t, "attempt 2", second.Comment)
+	assert.Equal(t, http.StatusServiceUnavailable, first.Response.Status)
+
+	request := second.Request
+	assert.Equal(t, http.MethodPut, request.Method)
+	assert.Equal(t, []HARNameValue{{Name: "a", Value: "1"}, {Name: "b", Value: "2"}}, request.QueryString)
+	assert.Contains(t, request.Headers, HARNameValue{Name: "Authorization", Value: "[REDACTED]"})
+	require.NotNil(t, request.PostData)
+	assert.Equal(t, "application/json", request.PostData.MimeType)
+	assert.Equal(t, `{"name":"jane"}`, request.PostData.Text)
+	assert.Equal(t, int64(len(`{"name":"jane"}`)), request.BodySize)
+
+	res := second.Response
+	assert.Equal(t, http.StatusCreated, res.Status)
+	assert.Equal(t, "Created", res.StatusText)
+	assert.Equal(t, "HTTP/1.1", res.HTTPVersion)
+	assert.Equal(t, []HARCookie{{Name: "session", Value: "[REDACTED]"}}, res.Cookies)
+	assert.Equal(t, `{"id":1}`, res.Content.Text)
+	assert.Equal(t, int64(len(`{"id":1}`)), res.Content.Size)
+}
+
+func TestHARRecorderRecordsCallsInSeparatePages(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	recorder := NewHARRecorder()
+	client := httpclient.NewClient()
+	client.AddPlugin(recorder)
+
+	for range 2 {
+		_, err := client.Get(server.URL, nil)
+		require.NoError(t, err)
+	}
+
+	log := recorder.HAR().Log
+	require.Len(t, log.Pages, 2)
+	require.Len(t, log.Entries, 2)
+	assert.NotEqual(t, log.Pages[0].ID, log.Pages[1].ID)
+	assert.Equal(t, log.Pages[0].ID, log.Entries[0].PageRef)
+	assert.Equal(t, log.Pages[1].ID, log.Entries[1].PageRef)
+}
+
+func TestHARRecorderRecordsErrors(t *testing.T) {
+	t.Parallel()
+
+	recorder := NewHARRecorder()
+	client := httpclient.NewClient(httpclient.WithHTTPTimeout(time.Second))
+	client.AddPlugin(recorder)
+
+	_, err := client.Get("http://localhost:0/", nil)
+	require.Error(t, err)
+
+	entries := recorder.HAR().Log.Entries
+	require.Len(t, entries, 1)
+	assert.Zero(t, entries[0].Response.Status)
+	assert.NotEmpty(t, entries[0].Response.Error)
+	assert.Equal(t, int64(-1), entries[0].Response.BodySize)
+}
+
+func TestHARRecorderTruncatesBodies(t *testing.T) {
+	t.Parallel()
+
+	responseBody := strings.Repeat("x", 100)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Length", "100")
+		_, _ = w.Write([]byte(responseBody))
+	}))
+	defer server.Close()
+
+	recorder := NewHARRecorder(WithHARMaxBodySize(10))
+	client := httpclient.NewClient()
+	client.AddPlugin(recorder)
+
+	response, err := client.Get(server.URL, nil)
+	require.NoError(t, err)
+	body, err := io.ReadAll(response.Body)
+	require.NoError(t, err)
+	assert.Equal(t, responseBody, string(body))
+
+	content := recorder.HAR().Log.Entries[0].Response.Content
+	assert.Equal(t, strings.Repeat("x", 10), content.Text)
+	assert.Equal(t, "truncated to 10 bytes", content.Comment)
+	assert.Equal(t, int64(100), content.Size)
+}
+
+func TestHARRecorderDropsTheOldestEntries(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	recorder := NewHARRecorder(WithHARMaxEntries(2))
+	client := httpclient.NewClient()
+	client.AddPlugin(recorder)
+
+	for _, path := range []string{"/1", "/2", "/3"} {
+		_, err := client.Get(server.URL+path, nil)
+		require.NoError(t, err)
+	}
+
+	log := recorder.HAR().Log
+	require.Len(t, log.Entries, 2)
+	assert.Equal(t, server.URL+"/2", log.Entries[0].Request.URL)
+	assert.Equal(t, server.URL+"/3", log.Entries[1].Request.URL)
+	require.Len(t, log.Pages, 2, "the page of the dropped call is dropped too")
+	assert.Equal(t, log.Entries[0].PageRef, log.Pages[0].ID)
+	assert.Equal(t, "1 older entries dropped", log.Comment)
+}
+
+func TestHARRecorderFlush(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	recorder := NewHARRecorder()
+	client := httpclient.NewClient()
+	client.AddPlugin(recorder)
+
+	_, err := client.Get(server.URL, nil)
+	require.NoError(t, err)
+
+	err = recorder.Flush(filepath.Join(t.TempDir(), "missing", "heimdall.har"))
+	require.Error(t, err)
+	assert.Len(t, recorder.HAR().Log.Entries, 1, "nothing is discarded if the file can't be written")
+
+	path := filepath.Join(t.TempDir(), "heimdall.har")
+	require.NoError(t, recorder.Flush(path))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	var har HAR
+	require.NoError(t, json.Unmarshal(data, &har))
+	assert.Len(t, har.Log.Entries, 1)
+	assert.Len(t, har.Log.Pages, 1)
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+	log := recorder.HAR().Log
+	assert.Empty(t, log.Entries, "a new document is started")
+	assert.Empty(t, log.Pages)
+}
+
+func TestHARAttemptTimings(t *testing.T) {
+	t.Parallel()
+
+	start := time.Now()
+	at := func(ms int) time.Time { return start.Add(time.Duration(ms) * time.Millisecond) }
+	attempt := &harAttempt{
+		start:        start,
+		dnsStart:     at(1),
+		dnsDone:      at(3),
+		connectStart: at(3),
+		connectDone:  at(5),
+		tlsStart:     at(5),
+		tlsDone:      at(9),
+		gotConn:      at(10),
+		wroteRequest: at(11),
+		firstByte:    at(30),
+	}
+
+	assert.Equal(t, HARTimings{
+		Blocked: 2,
+		DNS:     2,
+		Connect: 6, // includes the TLS handshake
+		SSL:     4,
+		Send:    1,
+		Wait:    19,
+		Receive: 5,
+	}, attempt.timings(at(35)))
+}
+
+func TestHARAttemptTimingsWithoutTrace(t *testing.T) {
+	t.Parallel()
+
+	start := time.Now()
+	attempt := &harAttempt{start: start}
+
+	assert.Equal(t, HARTimings{Blocked: -1, DNS: -1, Connect: -1, SSL: -1, Wait: 20},
+		attempt.timings(start.Add(20*time.Millisecond)))
+}
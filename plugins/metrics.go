@@ -0,0 +1,111 @@
+package plugins
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gojek/heimdall/v8"
+)
+
+const metricsCallStart ctxKey = "metrics_call_start"
+
+// MetricLabels are the labels of a measurement. StatusClass, e.g. "2xx", or "error" when no response was
+// received, is only set for calls and attempts.
+type MetricLabels struct {
+	Method      string
+	Host        string
+	StatusClass string
+}
+
+// MetricsCollector receives the measurements of the metrics plugin, e.g. to export them to a metrics backend.
+// Its methods may be called concurrently.
+type MetricsCollector interface {
+	// ObserveRequest records a call and its latency, across all its attempts
+	ObserveRequest(labels MetricLabels, latency time.Duration)
+	// ObserveAttempt records an attempt
+	ObserveAttempt(labels MetricLabels)
+	// ObserveRetry records a retry
+	ObserveRetry(labels MetricLabels)
+	// ObserveBudgetExhausted records a retry skipped because the retry error budget is exhausted
+	ObserveBudgetExhausted(labels MetricLabels)
+}
+
+type metrics struct {
+	collector MetricsCollector
+	clock     heimdall.Clock
+}
+
+// MetricsOption represents the metrics plugin options
+type MetricsOption func(*metrics)
+
+// WithMetricsClock sets the clock used to time calls
+func WithMetricsClock(clock heimdall.Clock) MetricsOption {
+	return func(m *metrics) {
+		m.clock = clock
+	}
+}
+
+// NewMetrics returns a new instance of a Heimdall plugin measuring calls, attempts, retries and retries skipped
+// by the retry error budget, by method and host, into the given collector, e.g. a PrometheusCollector.
+func NewMetrics(collector MetricsCollector, opts ...MetricsOption) heimdall.Plugin {
+	m := &metrics{
+		collector: collector,
+		clock:     heimdall.NewSystemClock(),
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+func (m *metrics) OnCallStart(req *http.Request) {
+	ctx := context.WithValue(req.Context(), metricsCallStart, m.clock.Now())
+	*req = *(req.WithContext(ctx))
+}
+
+func (m *metrics) OnCallEnd(req *http.Request, res *http.Response, _ int, err error) {
+	start, ok := req.Context().Value(metricsCallStart).(time.Time)
+	if !ok {
+		return
+	}
+
+	m.collector.ObserveRequest(metricLabels(req, statusClass(res, err)), m.clock.Now().Sub(start))
+}
+
+func (m *metrics) OnRequestStart(*http.Request) {}
+
+func (m *metrics) OnRequestEnd(req *http.Request, res *http.Response) {
+	m.collector.ObserveAttempt(metricLabels(req, statusClass(res, nil)))
+}
+
+func (m *metrics) OnError(req *http.Request, err error) {
+	m.collector.ObserveAttempt(metricLabels(req, statusClass(nil, err)))
+}
+
+func (m *metrics) OnRetry(req *http.Request, _ int, _ time.Duration, _ heimdall.Attempt) {
+	m.collector.ObserveRetry(metricLabels(req, ""))
+}
+
+func (m *metrics) OnBudgetExhausted(req *http.Request) {
+	m.collector.ObserveBudgetExhausted(metricLabels(req, ""))
+}
+
+func metricLabels(req *http.Request, statusClass string) MetricLabels {
+	host := req.Host
+	if req.URL != nil && req.URL.Host != "" {
+		host = req.URL.Host
+	}
+
+	return MetricLabels{Method: req.Method, Host: host, StatusClass: statusClass}
+}
+
+// statusClass returns the class of the status of the response, e.g. "2xx", or "error" without a response
+func statusClass(res *http.Response, err error) string {
+	if res == nil || (err != nil && res.StatusCode == 0) {
+		return "error"
+	}
+
+	return strconv.Itoa(res.StatusCode/100) + "xx"
+}
@@ -0,0 +1,153 @@
+package plugins
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gojek/heimdall/v8"
+	"github.com/gojek/heimdall/v8/httpclient"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recordingCollector struct {
+	mu              sync.Mutex
+	requests        []MetricLabels
+	latencies       []time.Duration
+	attempts        []MetricLabels
+	retries         []MetricLabels
+	budgetExhausted []MetricLabels
+}
+
+func (c *recordingCollector) ObserveRequest(labels MetricLabels, latency time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.requests = append(c.requests, labels)
+	c.latencies = append(c.latencies, latency)
+}
+
+func (c *recordingCollector) ObserveAttempt(labels MetricLabels) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.attempts = append(c.attempts, labels)
+}
+
+func (c *recordingCollector) ObserveRetry(labels MetricLabels) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.retries = append(c.retries, labels)
+}
+
+func (c *recordingCollector) ObserveBudgetExhausted(labels MetricLabels) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.budgetExhausted = append(c.budgetExhausted, labels)
+}
+
+// steppingClock moves a second forward every time it's read
+type steppingClock struct {
+	now time.Time
+}
+
+func (c *steppingClock) Now() time.Time {
+	c.now = c.now.Add(time.Second)
+	return c.now
+}
+
+func (c *steppingClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+func retryingClient() *httpclient.Client {
+	return httpclient.NewClient(
+		httpclient.WithHTTPTimeout(time.Second),
+		httpclient.WithRetryCount(1),
+		httpclient.WithRetrier(heimdall.NewRetrier(heimdall.NewConstantBackoff(time.Millisecond, 0))),
+	)
+}
+
+func TestMetricsRecordsAttemptsRetriesAndCalls(t *testing.T) {
+	t.Parallel()
+
+	count := atomic.Int32{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if count.Add(1) == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	collector := &recordingCollector{}
+	client := retryingClient()
+	client.AddPlugin(NewMetrics(collector, WithMetricsClock(&steppingClock{now: time.Now()})))
+
+	_, err := client.Get(server.URL, nil)
+	require.NoError(t, err)
+
+	host := strings.TrimPrefix(server.URL, "http://")
+	assert.Equal(t, []MetricLabels{
+		{Method: http.MethodGet, Host: host, StatusClass: "5xx"},
+		{Method: http.MethodGet, Host: host, StatusClass: "2xx"},
+	}, collector.attempts)
+	assert.Equal(t, []MetricLabels{{Method: http.MethodGet, Host: host}}, collector.retries)
+	assert.Equal(t, []MetricLabels{{Method: http.MethodGet, Host: host, StatusClass: "2xx"}}, collector.requests)
+	assert.Equal(t, []time.Duration{time.Second}, collector.latencies, "a call is timed once, across its attempts")
+	assert.Empty(t, collector.budgetExhausted)
+}
+
+func TestMetricsRecordsCallsGivingUp(t *testing.T) {
+	t.Parallel()
+
+	collector := &recordingCollector{}
+	client := retryingClient()
+	client.AddPlugin(NewMetrics(collector))
+
+	_, err := client.Get("http://localhost:0/", nil)
+	require.Error(t, err)
+
+	errorLabels := MetricLabels{Method: http.MethodGet, Host: "localhost:0", StatusClass: "error"}
+	assert.Equal(t, []MetricLabels{errorLabels, errorLabels}, collector.attempts)
+	assert.Len(t, collector.retries, 1)
+	assert.Equal(t, []MetricLabels{errorLabels}, collector.requests)
+}
+
+func TestMetricsHooks(t *testing.T) {
+	t.Parallel()
+
+	collector := &recordingCollector{}
+	plugin := NewMetrics(collector).(*metrics)
+
+	req, err := http.NewRequest(http.MethodPatch, "http://example.com/items/1", nil)
+	require.NoError(t, err)
+
+	plugin.OnBudgetExhausted(req)
+	assert.Equal(t, []MetricLabels{{Method: http.MethodPatch, Host: "example.com"}}, collector.budgetExhausted)
+
+	plugin.OnCallEnd(req, nil, 0, errors.New("rejected"))
+	assert.Empty(t, collector.requests, "calls whose start wasn't seen aren't recorded")
+
+	plugin.OnCallStart(req)
+	plugin.OnCallEnd(req, &http.Response{StatusCode: http.StatusTooManyRequests}, 1, nil)
+	assert.Equal(t, []MetricLabels{{Method: http.MethodPatch, Host: "example.com", StatusClass: "4xx"}}, collector.requests)
+}
+
+func TestStatusClass(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "2xx", statusClass(&http.Response{StatusCode: http.StatusNoContent}, nil))
+	assert.Equal(t, "5xx", statusClass(&http.Response{StatusCode: http.StatusBadGateway}, errors.New("retryable")))
+	assert.Equal(t, "error", statusClass(nil, errors.New("dial")))
+	assert.Equal(t, "error", statusClass(&http.Response{}, errors.New("dial")))
+}
@@ -0,0 +1,211 @@
+package plugins
+
+import (
+	"bufio"
+	"cmp"
+	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	_ MetricsCollector = (*PrometheusCollector)(nil)
+	_ http.Handler     = (*PrometheusCollector)(nil)
+)
+
+// DefaultLatencyBuckets are the default upper bounds of the latency histogram buckets, in seconds
+var DefaultLatencyBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+type histogram struct {
+	counts []uint64 // per bucket, the last one counting the observations above every bound
+	sum    float64
+}
+
+// PrometheusCollector is a MetricsCollector keeping the measurements in memory, and serving them over HTTP
+// in the Prometheus text exposition format, without any dependency. It is safe for concurrent use.
+type PrometheusCollector struct {
+	namespace string
+	buckets   []float64
+
+	mu              sync.Mutex
+	requests        map[MetricLabels]*histogram
+	attempts        map[MetricLabels]uint64
+	retries         map[MetricLabels]uint64
+	budgetExhausted map[MetricLabels]uint64
+}
+
+// PrometheusCollectorOption represents the Prometheus collector options
+type PrometheusCollectorOption func(*PrometheusCollector)
+
+// WithPrometheusNamespace sets the prefix of the metric names, "heimdall" by default
+func WithPrometheusNamespace(namespace string) PrometheusCollectorOption {
+	return func(pc *PrometheusCollector) {
+		pc.namespace = namespace
+	}
+}
+
+// WithPrometheusLatencyBuckets sets the upper bounds of the latency histogram buckets, in seconds,
+// DefaultLatencyBuckets by default
+func WithPrometheusLatencyBuckets(buckets ...float64) PrometheusCollectorOption {
+	return func(pc *PrometheusCollector) {
+		pc.buckets = slices.Compact(slices.Sorted(slices.Values(buckets)))
+	}
+}
+
+// NewPrometheusCollector returns a new PrometheusCollector, exposing these metrics:
+//   - <namespace>_requests_total, the calls by method, host and status class
+//   - <namespace>_request_duration_seconds, the histogram of the latency of calls by method, host and status class
+//   - <namespace>_attempts_total, the attempts by method, host and status class
+//   - <namespace>_retries_total, the retries by method and host
+//   - <namespace>_retry_budget_exhausted_total, the retries skipped by the retry error budget by method and host
+func NewPrometheusCollector(opts ...PrometheusCollectorOption) *PrometheusCollector {
+	pc := &PrometheusCollector{
+		namespace:       "heimdall",
+		buckets:         DefaultLatencyBuckets,
+		requests:        map[MetricLabels]*histogram{},
+		attempts:        map[MetricLabels]uint64{},
+		retries:         map[MetricLabels]uint64{},
+		budgetExhausted: map[MetricLabels]uint64{},
+	}
+	for _, opt := range opts {
+		opt(pc)
+	}
+	return pc
+}
+
+// ObserveRequest records a call and its latency
+func (pc *PrometheusCollector) ObserveRequest(labels MetricLabels, latency time.Duration) {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+
+	h, ok := pc.requests[labels]
+	if !ok {
+		h = &histogram{counts: make([]uint64, len(pc.buckets)+1)}
+		pc.requests[labels] = h
+	}
+
+	seconds := latency.Seconds()
+	bucket, _ := slices.BinarySearch(pc.buckets, seconds) // the first bound greater or equal to the latency
+	h.counts[bucket]++
+	h.sum += seconds
+}
+
+// ObserveAttempt records an attempt
+func (pc *PrometheusCollector) ObserveAttempt(labels MetricLabels) {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+
+	pc.attempts[labels]++
+}
+
+// ObserveRetry records a retry
+func (pc *PrometheusCollector) ObserveRetry(labels MetricLabels) {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+
+	pc.retries[labels]++
+}
+
+// ObserveBudgetExhausted records a retry skipped because the retry error budget is exhausted
+func (pc *PrometheusCollector) ObserveBudgetExhausted(labels MetricLabels) {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+
+	pc.budgetExhausted[labels]++
+}
+
+// ServeHTTP renders the metrics in the Prometheus text exposition format
+func (pc *PrometheusCollector) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+
+	out := bufio.NewWriter(w)
+	pc.write(out)
+	_ = out.Flush()
+}
+
+func (pc *PrometheusCollector) write(out *bufio.Writer) {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+
+	requests := sortedLabels(pc.requests)
+
+	name := pc.namespace + "_requests_total"
+	writeHeader(out, name, "counter", "Calls made, by method, host and status class.")
+	for _, labels := range requests {
+		h := pc.requests[labels]
+		fmt.Fprintf(out, "%s{%s} %d\n", name, formatLabels(labels, ""), h.count())
+	}
+
+	name = pc.namespace + "_request_duration_seconds"
+	writeHeader(out, name, "histogram", "Latency of calls across their attempts, by method, host and status class.")
+	for _, labels := range requests {
+		h := pc.requests[labels]
+		var cumulative uint64
+		for i, bound := range pc.buckets {
+			cumulative += h.counts[i]
+			le := strconv.FormatFloat(bound, 'g', -1, 64)
+			fmt.Fprintf(out, "%s_bucket{%s} %d\n", name, formatLabels(labels, le), cumulative)
+		}
+		fmt.Fprintf(out, "%s_bucket{%s} %d\n", name, formatLabels(labels, "+Inf"), h.count())
+		fmt.Fprintf(out, "%s_sum{%s} %s\n", name, formatLabels(labels, ""), strconv.FormatFloat(h.sum, 'g', -1, 64))
+		fmt.Fprintf(out, "%s_count{%s} %d\n", name, formatLabels(labels, ""), h.count())
+	}
+
+	writeCounter(out, pc.namespace+"_attempts_total", "Attempts made, by method, host and status class.", pc.attempts)
+	writeCounter(out, pc.namespace+"_retries_total", "Retries made, by method and host.", pc.retries)
+	writeCounter(out, pc.namespace+"_retry_budget_exhausted_total",
+		"Retries skipped because the retry error budget is exhausted, by method and host.", pc.budgetExhausted)
+}
+
+func (h *histogram) count() uint64 {
+	var count uint64
+	for _, c := range h.counts {
+		count += c
+	}
+	return count
+}
+
+func writeHeader(out *bufio.Writer, name, kind, help string) {
+	fmt.Fprintf(out, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+}
+
+func writeCounter(out *bufio.Writer, name, help string, counts map[MetricLabels]uint64) {
+	writeHeader(out, name, "counter", help)
+	for _, labels := range sortedLabels(counts) {
+		fmt.Fprintf(out, "%s{%s} %d\n", name, formatLabels(labels, ""), counts[labels])
+	}
+}
+
+// formatLabels returns the labels of a series, the status class only if set and the bucket bound only if given
+func formatLabels(labels MetricLabels, le string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, `host="%s",method="%s"`, escapeLabel(labels.Host), escapeLabel(labels.Method))
+	if labels.StatusClass != "" {
+		fmt.Fprintf(&b, `,status_class="%s"`, escapeLabel(labels.StatusClass))
+	}
+	if le != "" {
+		fmt.Fprintf(&b, `,le="%s"`, le)
+	}
+
+	return b.String()
+}
+
+func escapeLabel(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+}
+
+// sortedLabels returns the labels of the series in a stable order, for the output to be diffable
+func sortedLabels[V any](series map[MetricLabels]V) []MetricLabels {
+	return slices.SortedFunc(maps.Keys(series), func(a, b MetricLabels) int {
+		return cmp.Or(
+			cmp.Compare(a.Host, b.Host),
+			cmp.Compare(a.Method, b.Method),
+			cmp.Compare(a.StatusClass, b.StatusClass),
+		)
+	})
+}
@@ -0,0 +1,107 @@
+package plugins
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrometheusCollectorExposition(t *testing.T) {
+	t.Parallel()
+
+	collector := NewPrometheusCollector(WithPrometheusLatencyBuckets(1, 0.5, 1))
+
+	ok := MetricLabels{Method: http.MethodGet, Host: "api.example.com", StatusClass: "2xx"}
+	for _, latency := range []time.Duration{250 * time.Millisecond, 500 * time.Millisecond, 750 * time.Millisecond, 2 * time.Second} {
+		collector.ObserveRequest(ok, latency)
+	}
+	failed := MetricLabels{Method: http.MethodPost, Host: "we\"ird\\host\n", StatusClass: "error"}
+	collector.ObserveRequest(failed, time.Second)
+
+	collector.ObserveAttempt(ok)
+	collector.ObserveAttempt(ok)
+	collector.ObserveRetry(MetricLabels{Method: http.MethodGet, Host: "api.example.com"})
+
+	recorder := httptest.NewRecorder()
+	collector.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	assert.Equal(t, "text/plain; version=0.0.4; charset=utf-8", recorder.Header().Get("Content-Type"))
+	assert.Equal(t, strings.Join([]string{
+		`# HELP heimdall_requests_total Calls made, by method, host and status class.`,
+		`# TYPE heimdall_requests_total counter`,
+		`heimdall_requests_total{host="api.example.com",method="GET",status_class="2xx"} 4`,
+		`heimdall_requests_total{host="we\"ird\\host\n",method="POST",status_class="error"} 1`,
+		`# HELP heimdall_request_duration_seconds Latency of calls across their attempts, by method, host and status class.`,
+		`# TYPE heimdall_request_duration_seconds histogram`,
+		`heimdall_request_duration_seconds_bucket{host="api.example.com",method="GET",status_class="2xx",le="0.5"} 2`,
+		`heimdall_request_duration_seconds_bucket{host="api.example.com",method="GET",status_class="2xx",le="1"} 3`,
+		`heimdall_request_duration_seconds_bucket{host="api.example.com",method="GET",status_class="2xx",le="+Inf"} 4`,
+		`heimdall_request_duration_seconds_sum{host="api.example.com",method="GET",status_class="2xx"} 3.5`,
+		`heimdall_request_duration_seconds_count{host="api.example.com",method="GET",status_class="2xx"} 4`,
+		`heimdall_request_duration_seconds_bucket{host="we\"ird\\host\n",method="POST",status_class="error",le="0.5"} 0`,
+		`heimdall_request_duration_seconds_bucket{host="we\"ird\\host\n",method="POST",status_class="error",le="1"} 1`,
+		`heimdall_request_duration_seconds_bucket{host="we\"ird\\host\n",method="POST",status_class="error",le="+Inf"} 1`,
+		`heimdall_request_duration_seconds_sum{host="we\"ird\\host\n",method="POST",status_class="error"} 1`,
+		`heimdall_request_duration_seconds_count{host="we\"ird\\host\n",method="POST",status_class="error"} 1`,
+		`# HELP heimdall_attempts_total Attempts made, by method, host and status class.`,
+		`# TYPE heimdall_attempts_total counter`,
+		`heimdall_attempts_total{host="api.example.com",method="GET",status_class="2xx"} 2`,
+		`# HELP heimdall_retries_total Retries made, by method and host.`,
+		`# TYPE heimdall_retries_total counter`,
+		`heimdall_retries_total{host="api.example.com",method="GET"} 1`,
+		`# HELP heimdall_retry_budget_exhausted_total Retries skipped because the retry error budget is exhausted, by method and host.`,
+		`# TYPE heimdall_retry_budget_exhausted_total counter`,
+		``,
+	}, "\n"), recorder.Body.String())
+}
+
+func TestPrometheusCollectorOrdersSeries(t *testing.T) {
+	t.Parallel()
+
+	collector := NewPrometheusCollector(WithPrometheusNamespace("gateway"))
+	for _, labels := range []MetricLabels{
+		{Method: http.MethodPost, Host: "b.example.com", StatusClass: "5xx"},
+		{Method: http.MethodGet, Host: "b.example.com", StatusClass: "5xx"},
+		{Method: http.MethodGet, Host: "b.example.com", StatusClass: "2xx"},
+		{Method: http.MethodGet, Host: "a.example.com", StatusClass: "2xx"},
+	} {
+		collector.ObserveAttempt(labels)
+	}
+
+	recorder := httptest.NewRecorder()
+	collector.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	var series []string
+	for _, line := range strings.Split(recorder.Body.String(), "\n") {
+		if strings.HasPrefix(line, "gateway_attempts_total{") {
+			series = append(series, line)
+		}
+	}
+	assert.Equal(t, []string{
+		`gateway_attempts_total{host="a.example.com",method="GET",status_class="2xx"} 1`,
+		`gateway_attempts_total{host="b.example.com",method="GET",status_class="2xx"} 1`,
+		`gateway_attempts_total{host="b.example.com",method="GET",status_class="5xx"} 1`,
+		`gateway_attempts_total{host="b.example.com",method="POST",status_class="5xx"} 1`,
+	}, series)
+}
+
+func TestPrometheusCollectorDefaultBuckets(t *testing.T) {
+	t.Parallel()
+
+	collector := NewPrometheusCollector()
+	collector.ObserveRequest(MetricLabels{Method: http.MethodGet, Host: "example.com", StatusClass: "2xx"}, time.Minute)
+
+	recorder := httptest.NewRecorder()
+	collector.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	body := recorder.Body.String()
+	require.Equal(t, len(DefaultLatencyBuckets)+1, strings.Count(body, "heimdall_request_duration_seconds_bucket{"))
+	assert.Contains(t, body, `heimdall_request_duration_seconds_bucket{host="example.com",method="GET",status_class="2xx",le="10"} 0`)
+	assert.Contains(t, body, `heimdall_request_duration_seconds_bucket{host="example.com",method="GET",status_class="2xx",le="+Inf"} 1`)
+	assert.Contains(t, body, `heimdall_request_duration_seconds_sum{host="example.com",method="GET",status_class="2xx"} 60`)
+}
@@ -0,0 +1,183 @@
+package plugins
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const redacted = "[REDACTED]"
+
+// BearerTokenPattern matches bearer tokens, e.g. in Authorization headers or bodies
+var BearerTokenPattern = regexp.MustCompile(`(?i)bearer\s+[a-z0-9._~+/=-]+`)
+
+// cardNumberCandidate matches numbers of 13 to 19 digits starting like the numbers of the major card networks,
+// optionally grouped by spaces or dashes. Only those passing the Luhn check are masked.
+var cardNumberCandidate = regexp.MustCompile(`\b[2-6](?:[ -]?\d){12,18}\b`)
+
+// Redaction is a set of rules masking secrets in captured requests and responses
+type Redaction struct {
+	Headers     []string         // Names of the headers whose values are masked
+	JSONPaths   []string         // Dot separated paths of the JSON body fields to mask, e.g. card.number or items.*.token
+	Patterns    []*regexp.Regexp // Patterns masked in URLs, header values and bodies
+	CardNumbers bool             // Masks card numbers in URLs, header values and bodies
+}
+
+// DefaultRedaction returns rules masking the Authorization, Proxy-Authorization, Cookie and Set-Cookie headers,
+// bearer tokens and card numbers.
+func DefaultRedaction() Redaction {
+	return Redaction{
+		Headers:     []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie"},
+		Patterns:    []*regexp.Regexp{BearerTokenPattern},
+		CardNumbers: true,
+	}
+}
+
+// RedactHeader returns a copy of the header with the values of the redacted headers masked
+func (r Redaction) RedactHeader(header http.Header) http.Header {
+	result := header.Clone()
+	for name, values := range result {
+		masked := r.isRedactedHeader(name)
+		for i, value := range values {
+			if masked {
+				values[i] = redacted
+				continue
+			}
+			values[i] = r.RedactString(value)
+		}
+	}
+
+	return result
+}
+
+// RedactBody returns a copy of the body with the redacted JSON fields and patterns masked.
+// When JSON paths are set, a body which looks like JSON but can't be parsed is masked as a whole.
+func (r Redaction) RedactBody(body []byte) []byte {
+	if len(body) == 0 {
+		return body
+	}
+
+	if len(r.JSONPaths) > 0 {
+		body = r.redactJSON(body)
+	}
+
+	return r.redactPatterns(body)
+}
+
+// RedactTruncatedBody returns a copy of the beginning of a body cut at a size limit, with the patterns masked.
+// JSON fields can't be found reliably in an incomplete document, so the body is masked as a whole when
+// JSON paths are set.
+func (r Redaction) RedactTruncatedBody(body []byte) []byte {
+	if len(body) == 0 {
+		return body
+	}
+
+	if len(r.JSONPaths) > 0 {
+		return []byte(redacted)
+	}
+
+	return r.redactPatterns(body)
+}
+
+// RedactString returns the string with the redacted patterns masked, e.g. for URLs
+func (r Redaction) RedactString(s string) string {
+	return string(r.redactPatterns([]byte(s)))
+}
+
+func (r Redaction) redactPatterns(data []byte) []byte {
+	for _, pattern := range r.Patterns {
+		data = pattern.ReplaceAll(data, []byte(redacted))
+	}
+
+	if r.CardNumbers {
+		data = cardNumberCandidate.ReplaceAllFunc(data, func(number []byte) []byte {
+			if !luhnValid(number) {
+				return number
+			}
+			return []byte(redacted)
+		})
+	}
+
+	return data
+}
+
+// luhnValid reports whether the digits of the number, ignoring separators, pass the Luhn checksum
+func luhnValid(number []byte) bool {
+	sum, double := 0, false
+	for i := len(number) - 1; i >= 0; i-- {
+		if number[i] < '0' || number[i] > '9' {
+			continue
+		}
+
+		digit := int(number[i] - '0')
+		if double {
+			digit *= 2
+			if digit > 9 {
+				digit -= 9
+			}
+		}
+		sum += digit
+		double = !double
+	}
+
+	return sum%10 == 0
+}
+
+func (r Redaction) isRedactedHeader(name string) bool {
+	for _, header := range r.Headers {
+		if strings.EqualFold(header, name) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (r Redaction) redactJSON(body []byte) []byte {
+	var document any
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber() // keep numbers as they were written
+	if err := decoder.Decode(&document); err != nil || decoder.More() {
+		if trimmed := bytes.TrimSpace(body); len(trimmed) > 0 && (trimmed[0] == '{' || trimmed[0] == '[') {
+			return []byte(redacted) // not a complete JSON document, its fields can't be found reliably
+		}
+		return body
+	}
+
+	for _, path := range r.JSONPaths {
+		document = redactJSONPath(document, strings.Split(path, "."))
+	}
+
+	redactedBody, err := json.Marshal(document)
+	if err != nil {
+		return body
+	}
+
+	return redactedBody
+}
+
+func redactJSONPath(value any, path []string) any {
+	if len(path) == 0 {
+		return redacted
+	}
+
+	switch v := value.(type) {
+	case map[string]any:
+		for key, field := range v {
+			if path[0] == "*" || path[0] == key {
+				v[key] = redactJSONPath(field, path[1:])
+			}
+		}
+	case []any:
+		for i, element := range v {
+			if path[0] == "*" || path[0] == strconv.Itoa(i) {
+				v[i] = redactJSONPath(element, path[1:])
+			}
+		}
+	}
+
+	return value
+}
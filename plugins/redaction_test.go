@@ -0,0 +1,107 @@
+package plugins
+
+import (
+	"net/http"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedactHeader(t *testing.T) {
+	t.Parallel()
+
+	header := http.Header{
+		"Authorization": {"Basic dXNlcjpwYXNz"},
+		"Set-Cookie":    {"a=1", "b=2"},
+		"X-Trace":       {"bearer abc.def"},
+		"Accept":        {"application/json"},
+	}
+
+	redacted := DefaultRedaction().RedactHeader(header)
+
+	assert.Equal(t, []string{"[REDACTED]"}, redacted["Authorization"])
+	assert.Equal(t, []string{"[REDACTED]", "[REDACTED]"}, redacted["Set-Cookie"])
+	assert.Equal(t, []string{"[REDACTED]"}, redacted["X-Trace"])
+	assert.Equal(t, []string{"application/json"}, redacted["Accept"])
+	assert.Equal(t, "Basic dXNlcjpwYXNz", header.Get("Authorization"), "the original header is left untouched")
+}
+
+func TestRedactHeaderMatchesNamesCaseInsensitively(t *testing.T) {
+	t.Parallel()
+
+	redaction := Redaction{Headers: []string{"x-api-key"}}
+
+	redacted := redaction.RedactHeader(http.Header{"X-Api-Key": {"secret"}})
+
+	assert.Equal(t, "[REDACTED]", redacted.Get("X-Api-Key"))
+	assert.Nil(t, redaction.RedactHeader(nil))
+}
+
+func TestRedactBodyJSONPaths(t *testing.T) {
+	t.Parallel()
+
+	redaction := Redaction{JSONPaths: []string{"card.number", "items.*.token", "list.1"}}
+
+	body := redaction.RedactBody([]byte(`{
+		"card": {"number": "4111111111111111", "holder": "J. Doe"},
+		"items": [{"token": "t1", "id": 1}, {"token": "t2", "id": 12345678901234567890}],
+		"list": ["a", "b", "c"]
+	}`))
+
+	assert.JSONEq(t, `{
+		"card": {"number": "[REDACTED]", "holder": "J. Doe"},
+		"items": [{"token": "[REDACTED]", "id": 1}, {"token": "[REDACTED]", "id": 12345678901234567890}],
+		"list": ["a", "[REDACTED]", "c"]
+	}`, string(body))
+}
+
+func TestRedactBodyMasksUnparsableJSON(t *testing.T) {
+	t.Parallel()
+
+	redaction := Redaction{JSONPaths: []string{"password"}}
+
+	assert.Equal(t, "[REDACTED]", string(redaction.RedactBody([]byte(`{"password": "hunter2"`))))
+	assert.Equal(t, "[REDACTED]", string(redaction.RedactBody([]byte("{\"password\": 1}\n{\"password\": 2}"))))
+	assert.Equal(t, "password=hunter2", string(redaction.RedactBody([]byte("password=hunter2"))), "not JSON")
+}
+
+func TestRedactTruncatedBody(t *testing.T) {
+	t.Parallel()
+
+	withPaths := Redaction{JSONPaths: []string{"password"}}
+	assert.Equal(t, "[REDACTED]", string(withPaths.RedactTruncatedBody([]byte(`{"user": "jane", "password": "hun`))))
+
+	withPatterns := Redaction{Patterns: []*regexp.Regexp{regexp.MustCompile(`secret-\w+`)}}
+	assert.Equal(t, `{"key": "[REDACTED]", "rest": "tr`,
+		string(withPatterns.RedactTruncatedBody([]byte(`{"key": "secret-abc", "rest": "tr`))))
+
+	assert.Empty(t, withPaths.RedactTruncatedBody(nil))
+}
+
+func TestRedactStringPatterns(t *testing.T) {
+	t.Parallel()
+
+	redaction := DefaultRedaction()
+
+	assert.Equal(t, "token: [REDACTED]", redaction.RedactString("token: Bearer eyJhbGciOi.J9.sig"))
+	assert.Equal(t, "card [REDACTED] end", redaction.RedactString("card 4111 1111 1111 1111 end"))
+	assert.Equal(t, "card [REDACTED] end", redaction.RedactString("card 5500-0000-0000-0004 end"))
+}
+
+func TestRedactStringCardNumbersRequireLuhn(t *testing.T) {
+	t.Parallel()
+
+	redaction := DefaultRedaction()
+
+	for _, value := range []string{
+		"1700000000000",        // epoch milliseconds
+		"4111111111111112",     // fails the Luhn check
+		"5000000000000",        // numeric ID
+		"12345678901234567890", // too long
+	} {
+		assert.Equal(t, "id="+value, redaction.RedactString("id="+value), value)
+	}
+
+	assert.Equal(t, "4111111111111111", Redaction{}.RedactString("4111111111111111"), "card numbers are opt-in")
+}
@@ -18,47 +18,64 @@ const reqTime ctxKey = "request_time_start"
 type requestLogger struct {
 	out    io.Writer
 	errOut io.Writer
+	clock  heimdall.Clock
+}
+
+// RequestLoggerOption represents the request logger options
+type RequestLoggerOption func(*requestLogger)
+
+// WithRequestLoggerClock sets the clock used to time requests and to stamp log lines
+func WithRequestLoggerClock(clock heimdall.Clock) RequestLoggerOption {
+	return func(rl *requestLogger) {
+		rl.clock = clock
+	}
 }
 
 // NewRequestLogger returns a new instance of a Heimdall request logger plugin
 // out and errOut are the streams where standard and error logs are written respectively
 // If given as nil, `out` takes the default value of `os.StdOut`
 // and errOut takes the default value of `os.StdErr`
-func NewRequestLogger(out io.Writer, errOut io.Writer) heimdall.Plugin {
+func NewRequestLogger(out io.Writer, errOut io.Writer, opts ...RequestLoggerOption) heimdall.Plugin {
 	if out == nil {
 		out = os.Stdout
 	}
 	if errOut == nil {
 		errOut = os.Stderr
 	}
-	return &requestLogger{
+	rl := &requestLogger{
 		out:    out,
 		errOut: errOut,
+		clock:  heimdall.NewSystemClock(),
+	}
+	for _, opt := range opts {
+		opt(rl)
 	}
+	return rl
 }
 
 func (rl *requestLogger) OnRequestStart(req *http.Request) {
-	ctx := context.WithValue(req.Context(), reqTime, time.Now())
+	ctx := context.WithValue(req.Context(), reqTime, rl.clock.Now())
 	*req = *(req.WithContext(ctx))
 }
 
 func (rl *requestLogger) OnRequestEnd(req *http.Request, res *http.Response) {
-	reqDuration := getRequestDuration(req.Context()) / time.Millisecond
+	now := rl.clock.Now()
+	reqDuration := getRequestDuration(req.Context(), now) / time.Millisecond
 	method := req.Method
 	url := req.URL.String()
 	statusCode := res.StatusCode
-	fmt.Fprintf(rl.out, "%s %s %s %d [%dms]\n", time.Now().Format("02/Jan/2006 03:04:05"), method, url, statusCode, reqDuration)
+	fmt.Fprintf(rl.out, "%s %s %s %d [%dms]\n", now.Format("02/Jan/2006 03:04:05"), method, url, statusCode, reqDuration)
 }
 
 func (rl *requestLogger) OnError(req *http.Request, err error) {
-	reqDuration := getRequestDuration(req.Context()) / time.Millisecond
+	now := rl.clock.Now()
+	reqDuration := getRequestDuration(req.Context(), now) / time.Millisecond
 	method := req.Method
 	url := req.URL.String()
-	fmt.Fprintf(rl.errOut, "%s %s %s [%dms] ERROR: %v\n", time.Now().Format("02/Jan/2006 03:04:05"), method, url, reqDuration, err)
+	fmt.Fprintf(rl.errOut, "%s %s %s [%dms] ERROR: %v\n", now.Format("02/Jan/2006 03:04:05"), method, url, reqDuration, err)
 }
 
-func getRequestDuration(ctx context.Context) time.Duration {
-	now := time.Now()
+func getRequestDuration(ctx context.Context, now time.Time) time.Duration {
 	start := ctx.Value(reqTime)
 	if start == nil {
 		return 0
@@ -0,0 +1,200 @@
+package plugins
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gojek/heimdall/v8"
+)
+
+const slogReqTime ctxKey = "slog_request_time_start"
+
+// SlogLevels sets the level of the log records by outcome of the request
+type SlogLevels struct {
+	Success     slog.Level // 1xx, 2xx and 3xx responses
+	ClientError slog.Level // 4xx responses
+	ServerError slog.Level // 5xx responses
+	Error       slog.Level // requests failing without a response
+}
+
+type slogLogger struct {
+	logger          *slog.Logger
+	levels          SlogLevels
+	requestHeaders  []string
+	responseHeaders []string
+	redactedParams  []string
+	redactAllParams bool
+	clock           heimdall.Clock
+}
+
+// SlogLoggerOption represents the slog logger options
+type SlogLoggerOption func(*slogLogger)
+
+// WithSlogLevels sets the level of the log records by outcome of the request.
+// By default, successful requests are logged at debug level, 4xx at warn level, 5xx and errors at error level.
+func WithSlogLevels(levels SlogLevels) SlogLoggerOption {
+	return func(sl *slogLogger) {
+		sl.levels = levels
+	}
+}
+
+// WithSlogRequestHeaders sets the request headers to log
+func WithSlogRequestHeaders(names ...string) SlogLoggerOption {
+	return func(sl *slogLogger) {
+		sl.requestHeaders = canonicalHeaders(names)
+	}
+}
+
+// WithSlogResponseHeaders sets the response headers to log
+func WithSlogResponseHeaders(names ...string) SlogLoggerOption {
+	return func(sl *slogLogger) {
+		sl.responseHeaders = canonicalHeaders(names)
+	}
+}
+
+// WithSlogRedactedQueryParams redacts the values of the given query parameters in the logged URLs,
+// or the values of all query parameters when none is given.
+func WithSlogRedactedQueryParams(names ...string) SlogLoggerOption {
+	return func(sl *slogLogger) {
+		sl.redactedParams = names
+		sl.redactAllParams = len(names) == 0
+	}
+}
+
+// WithSlogClock sets the clock used to time requests
+func WithSlogClock(clock heimdall.Clock) SlogLoggerOption {
+	return func(sl *slogLogger) {
+		sl.clock = clock
+	}
+}
+
+// NewSlogLogger returns a new instance of a Heimdall plugin logging every attempt as a structured record
+// with the method, URL, status, duration, attempt number and error. If given as nil, `logger` takes the
+// default value of `slog.Default()`
+func NewSlogLogger(logger *slog.Logger, opts ...SlogLoggerOption) heimdall.Plugin {
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	sl := &slogLogger{
+		logger: logger,
+		levels: SlogLevels{
+			Success:     slog.LevelDebug,
+			ClientError: slog.LevelWarn,
+			ServerError: slog.LevelError,
+			Error:       slog.LevelError,
+		},
+		clock: heimdall.NewSystemClock(),
+	}
+	for _, opt := range opts {
+		opt(sl)
+	}
+	return sl
+}
+
+func (sl *slogLogger) OnRequestStart(req *http.Request) {
+	ctx := context.WithValue(req.Context(), slogReqTime, sl.clock.Now())
+	*req = *(req.WithContext(ctx))
+}
+
+func (sl *slogLogger) OnRequestEnd(req *http.Request, res *http.Response) {
+	level := sl.levels.Success
+	switch {
+	case res.StatusCode >= http.StatusInternalServerError:
+		level = sl.levels.ServerError
+	case res.StatusCode >= http.StatusBadRequest:
+		level = sl.levels.ClientError
+	}
+
+	if !sl.logger.Enabled(req.Context(), level) {
+		return
+	}
+
+	attrs := append(sl.requestAttrs(req), slog.Int("status", res.StatusCode))
+	if header := headerGroup("response_headers", res.Header, sl.responseHeaders); header.Key != "" {
+		attrs = append(attrs, header)
+	}
+
+	sl.logger.LogAttrs(req.Context(), level, "request completed", attrs...)
+}
+
+func (sl *slogLogger) OnError(req *http.Request, err error) {
+	if !sl.logger.Enabled(req.Context(), sl.levels.Error) {
+		return
+	}
+
+	attrs := append(sl.requestAttrs(req), slog.String("error", err.Error()))
+	sl.logger.LogAttrs(req.Context(), sl.levels.Error, "request failed", attrs...)
+}
+
+func (sl *slogLogger) requestAttrs(req *http.Request) []slog.Attr {
+	attrs := []slog.Attr{
+		slog.String("method", req.Method),
+		slog.String("url", sl.redactURL(req.URL)),
+	}
+
+	if start, ok := req.Context().Value(slogReqTime).(time.Time); ok {
+		attrs = append(attrs, slog.Duration("duration", sl.clock.Now().Sub(start)))
+	}
+	if attempt, ok := heimdall.AttemptFromContext(req.Context()); ok {
+		attrs = append(attrs, slog.Int("attempt", attempt))
+	}
+	if header := headerGroup("request_headers", req.Header, sl.requestHeaders); header.Key != "" {
+		attrs = append(attrs, header)
+	}
+
+	return attrs
+}
+
+// redactURL returns the URL with its password and the values of the redacted query parameters masked
+func (sl *slogLogger) redactURL(u *url.URL) string {
+	if u == nil {
+		return ""
+	}
+
+	if u.RawQuery == "" || (!sl.redactAllParams && len(sl.redactedParams) == 0) {
+		return u.Redacted()
+	}
+
+	query := u.Query()
+	for name, values := range query {
+		if sl.redactAllParams || slices.Contains(sl.redactedParams, name) {
+			for i := range values {
+				values[i] = "xxxxx"
+			}
+		}
+	}
+
+	redacted := *u
+	redacted.RawQuery = query.Encode()
+	return redacted.Redacted()
+}
+
+// headerGroup returns the given headers as an attribute group, or an empty attribute if none is set
+func headerGroup(key string, header http.Header, names []string) slog.Attr {
+	var attrs []any
+	for _, name := range names {
+		if values := header.Values(name); len(values) > 0 {
+			attrs = append(attrs, slog.String(name, strings.Join(values, ", ")))
+		}
+	}
+
+	if len(attrs) == 0 {
+		return slog.Attr{}
+	}
+
+	return slog.Group(key, attrs...)
+}
+
+func canonicalHeaders(names []string) []string {
+	canonical := make([]string, len(names))
+	for i, name := range names {
+		canonical[i] = http.CanonicalHeaderKey(name)
+	}
+	return canonical
+}
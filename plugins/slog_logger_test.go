@@ -0,0 +1,215 @@
+package plugins
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gojek/heimdall/v8/httpclient"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// syncBuffer is a buffer safe for concurrent writes, e.g. by the attempts of a call
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.buf.Write(p)
+}
+
+// records returns the JSON records written so far
+func (b *syncBuffer) records(t *testing.T) []map[string]any {
+	t.Helper()
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var records []map[string]any
+	for line := range strings.Lines(b.buf.String()) {
+		var record map[string]any
+		require.NoError(t, json.Unmarshal([]byte(line), &record))
+		records = append(records, record)
+	}
+	return records
+}
+
+func jsonLogger(out *syncBuffer, level slog.Level) *slog.Logger {
+	return slog.New(slog.NewJSONHandler(out, &slog.HandlerOptions{Level: level}))
+}
+
+func statusServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Request-Id", r.Header.Get("X-Request-Id"))
+		w.Header().Set("X-Secret", "s3cret")
+		switch r.URL.Path {
+		case "/not-found":
+			w.WriteHeader(http.StatusNotFound)
+		case "/broken":
+			w.WriteHeader(http.StatusBadGateway)
+		default:
+			w.WriteHeader(http.StatusOK)
+		}
+	}))
+}
+
+func TestSlogLoggerLevelsByStatus(t *testing.T) {
+	t.Parallel()
+
+	server := statusServer(t)
+	defer server.Close()
+
+	var out syncBuffer
+	client := httpclient.NewClient(httpclient.WithHTTPTimeout(time.Second))
+	client.AddPlugin(NewSlogLogger(jsonLogger(&out, slog.LevelDebug)))
+
+	for _, path := range []string{"/", "/not-found", "/broken"} {
+		_, err := client.Get(server.URL+path, nil)
+		require.NoError(t, err)
+	}
+	_, err := client.Get("http://localhost:0/", nil)
+	require.Error(t, err)
+
+	records := out.records(t)
+	require.Len(t, records, 4)
+	for i, expected := range []struct {
+		level  string
+		msg    string
+		status float64
+	}{
+		{"DEBUG", "request completed", http.StatusOK},
+		{"WARN", "request completed", http.StatusNotFound},
+		{"ERROR", "request completed", http.StatusBadGateway},
+		{"ERROR", "request failed", 0},
+	} {
+		assert.Equal(t, expected.level, records[i]["level"], i)
+		assert.Equal(t, expected.msg, records[i]["msg"], i)
+		if expected.status != 0 {
+			assert.Equal(t, expected.status, records[i]["status"], i)
+		}
+	}
+	assert.NotEmpty(t, records[3]["error"])
+	assert.NotContains(t, records[3], "status")
+}
+
+func TestSlogLoggerCustomLevels(t *testing.T) {
+	t.Parallel()
+
+	server := statusServer(t)
+	defer server.Close()
+
+	var out syncBuffer
+	client := httpclient.NewClient()
+	client.AddPlugin(NewSlogLogger(jsonLogger(&out, slog.LevelInfo), WithSlogLevels(SlogLevels{
+		Success:     slog.LevelInfo,
+		ClientError: slog.LevelDebug,
+		ServerError: slog.LevelWarn,
+		Error:       slog.LevelError,
+	})))
+
+	for _, path := range []string{"/", "/not-found", "/broken"} {
+		_, err := client.Get(server.URL+path, nil)
+		require.NoError(t, err)
+	}
+
+	records := out.records(t)
+	require.Len(t, records, 2, "the 4xx record is below the handler level")
+	assert.Equal(t, "INFO", records[0]["level"])
+	assert.Equal(t, "WARN", records[1]["level"])
+	assert.Equal(t, float64(http.StatusBadGateway), records[1]["status"])
+}
+
+func TestSlogLoggerAttributes(t *testing.T) {
+	t.Parallel()
+
+	server := statusServer(t)
+	defer server.Close()
+
+	var out syncBuffer
+	client := httpclient.NewClient()
+	client.AddPlugin(NewSlogLogger(jsonLogger(&out, slog.LevelDebug),
+		WithSlogClock(&steppingClock{now: time.Now()}),
+		WithSlogRequestHeaders("x-request-id", "X-Missing"),
+		WithSlogResponseHeaders("X-Request-Id"),
+	))
+
+	_, err := client.Get(server.URL+"/items", http.Header{"X-Request-Id": {"req-1"}, "Authorization": {"secret"}})
+	require.NoError(t, err)
+
+	records := out.records(t)
+	require.Len(t, records, 1)
+	record := records[0]
+	assert.Equal(t, http.MethodGet, record["method"])
+	assert.Equal(t, server.URL+"/items", record["url"])
+	assert.Equal(t, float64(1), record["attempt"])
+	assert.Equal(t, float64(time.Second), record["duration"])
+	assert.Equal(t, map[string]any{"X-Request-Id": "req-1"}, record["request_headers"])
+	assert.Equal(t, map[string]any{"X-Request-Id": "req-1"}, record["response_headers"])
+}
+
+func TestSlogLoggerOmitsEmptyHeaderGroups(t *testing.T) {
+	t.Parallel()
+
+	server := statusServer(t)
+	defer server.Close()
+
+	var out syncBuffer
+	client := httpclient.NewClient()
+	client.AddPlugin(NewSlogLogger(jsonLogger(&out, slog.LevelDebug), WithSlogRequestHeaders("X-Missing")))
+
+	_, err := client.Get(server.URL, nil)
+	require.NoError(t, err)
+
+	records := out.records(t)
+	require.Len(t, records, 1)
+	assert.NotContains(t, records[0], "request_headers")
+	assert.NotContains(t, records[0], "response_headers")
+}
+
+func TestSlogLoggerRedactsURLs(t *testing.T) {
+	t.Parallel()
+
+	server := statusServer(t)
+	t.Cleanup(server.Close) // outlives the parallel subtests
+	userURL := strings.Replace(server.URL, "http://", "http://user:password@", 1)
+
+	tests := []struct {
+		name     string
+		opts     []SlogLoggerOption
+		expected string
+	}{
+		{"without redaction", nil, userURL + "/?token=abc&page=2"},
+		{"named parameters", []SlogLoggerOption{WithSlogRedactedQueryParams("token")}, userURL + "/?page=2&token=xxxxx"},
+		{"every parameter", []SlogLoggerOption{WithSlogRedactedQueryParams()}, userURL + "/?page=xxxxx&token=xxxxx"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var out syncBuffer
+			client := httpclient.NewClient()
+			client.AddPlugin(NewSlogLogger(jsonLogger(&out, slog.LevelDebug), tt.opts...))
+
+			_, err := client.Get(userURL+"/?token=abc&page=2", nil)
+			require.NoError(t, err)
+
+			records := out.records(t)
+			require.Len(t, records, 1)
+			expected := strings.Replace(tt.expected, ":password@", ":xxxxx@", 1)
+			assert.Equal(t, expected, records[0]["url"])
+		})
+	}
+}
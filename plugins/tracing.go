@@ -0,0 +1,155 @@
+package plugins
+
+import (
+	"context"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gojek/heimdall/v8"
+)
+
+const (
+	tracingCallSpan    ctxKey = "tracing_call_span"
+	tracingAttemptSpan ctxKey = "tracing_attempt_span"
+
+	// TraceparentHeader is the W3C Trace Context header carrying the trace and span IDs of a request
+	TraceparentHeader = "traceparent"
+	// TracestateHeader is the W3C Trace Context header carrying the vendor specific trace state of a request
+	TracestateHeader = "tracestate"
+)
+
+// SpanContext identifies a span across processes, as propagated by the W3C Trace Context headers
+type SpanContext struct {
+	TraceID    [16]byte
+	SpanID     [8]byte
+	Sampled    bool
+	TraceState string // sent as is in the tracestate header
+}
+
+// IsValid reports whether both the trace and span IDs are set
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID != [16]byte{} && sc.SpanID != [8]byte{}
+}
+
+// Traceparent returns the value of the traceparent header identifying the span
+func (sc SpanContext) Traceparent() string {
+	flags := "00"
+	if sc.Sampled {
+		flags = "01"
+	}
+
+	return "00-" + hex.EncodeToString(sc.TraceID[:]) + "-" + hex.EncodeToString(sc.SpanID[:]) + "-" + flags
+}
+
+// Span is a span started by a Tracer
+type Span interface {
+	SpanContext() SpanContext
+	SetAttribute(key string, value any)
+	RecordError(err error)
+	End()
+}
+
+// Tracer starts spans, e.g. by adapting an OpenTelemetry tracer. Start is given the context of the request,
+// carrying the parent span if any, and returns a context carrying the new span.
+type Tracer interface {
+	Start(ctx context.Context, name string) (context.Context, Span)
+}
+
+type tracing struct {
+	tracer Tracer
+}
+
+// NewTracing returns a new instance of a Heimdall plugin tracing calls with the given tracer. Every call gets
+// a client span, with a child span per attempt whose context is sent in the traceparent and tracestate headers.
+// Spans record the method, URL and status code, call spans also record the number of retries and whether
+// the call was rejected by an open circuit.
+func NewTracing(tracer Tracer) heimdall.Plugin {
+	return &tracing{tracer: tracer}
+}
+
+func (t *tracing) OnCallStart(req *http.Request) {
+	ctx, span := t.tracer.Start(req.Context(), "HTTP "+req.Method)
+	setRequestAttributes(span, req)
+
+	ctx = context.WithValue(ctx, tracingCallSpan, span)
+	*req = *(req.WithContext(ctx))
+}
+
+func (t *tracing) OnCallEnd(req *http.Request, res *http.Response, attempts int, err error) {
+	span, ok := req.Context().Value(tracingCallSpan).(Span)
+	if !ok {
+		return
+	}
+
+	span.SetAttribute("heimdall.retry_count", max(attempts-1, 0))
+	endSpan(span, res, err)
+}
+
+func (t *tracing) OnRequestStart(req *http.Request) {
+	ctx, span := t.tracer.Start(req.Context(), "HTTP "+req.Method+" attempt")
+	setRequestAttributes(span, req)
+	if attempt, ok := heimdall.AttemptFromContext(req.Context()); ok && attempt > 1 {
+		span.SetAttribute("http.request.resend_count", attempt-1)
+	}
+
+	if sc := span.SpanContext(); sc.IsValid() {
+		// set on a copy, the headers of the caller are shared by every attempt
+		req.Header = req.Header.Clone()
+		if req.Header == nil {
+			req.Header = http.Header{}
+		}
+		for key := range req.Header {
+			// keys set without canonicalisation, e.g. "traceparent", would otherwise be sent along
+			if strings.EqualFold(key, TraceparentHeader) || strings.EqualFold(key, TracestateHeader) {
+				delete(req.Header, key)
+			}
+		}
+		req.Header.Set(TraceparentHeader, sc.Traceparent())
+		if sc.TraceState != "" {
+			req.Header.Set(TracestateHeader, sc.TraceState)
+		}
+	}
+
+	ctx = context.WithValue(ctx, tracingAttemptSpan, span)
+	*req = *(req.WithContext(ctx))
+}
+
+func (t *tracing) OnRequestEnd(req *http.Request, res *http.Response) {
+	if span, ok := req.Context().Value(tracingAttemptSpan).(Span); ok {
+		endSpan(span, res, nil)
+	}
+}
+
+func (t *tracing) OnError(req *http.Request, err error) {
+	if span, ok := req.Context().Value(tracingAttemptSpan).(Span); ok {
+		endSpan(span, nil, err)
+	}
+}
+
+func (t *tracing) OnCircuitOpen(req *http.Request) {
+	if span, ok := req.Context().Value(tracingCallSpan).(Span); ok {
+		span.SetAttribute("heimdall.circuit_open", true)
+	}
+}
+
+func setRequestAttributes(span Span, req *http.Request) {
+	span.SetAttribute("http.request.method", req.Method)
+	span.SetAttribute("url.full", req.URL.Redacted())
+}
+
+// endSpan records the outcome of a call or attempt, 4xx and 5xx responses being errors of the client span
+func endSpan(span Span, res *http.Response, err error) {
+	if res != nil {
+		span.SetAttribute("http.response.status_code", res.StatusCode)
+		if res.StatusCode >= http.StatusBadRequest {
+			span.SetAttribute("error.type", strconv.Itoa(res.StatusCode))
+		}
+	}
+	if err != nil {
+		span.RecordError(err)
+	}
+
+	span.End()
+}
@@ -0,0 +1,150 @@
+package plugins_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gojek/heimdall/v8/circuitbreaker"
+	"github.com/gojek/heimdall/v8/heimdalltest"
+	"github.com/gojek/heimdall/v8/httpclient"
+	"github.com/gojek/heimdall/v8/plugins"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// the tests are external to the package, as heimdalltest imports it for its RecordingTracer
+
+type doerFunc func(*http.Request) (*http.Response, error)
+
+func (f doerFunc) Do(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestTracingPlugin(t *testing.T) {
+	t.Parallel()
+
+	var traceparents []string
+	client := httpclient.NewClient(
+		httpclient.WithRetryCount(1),
+		httpclient.WithHTTPClient(doerFunc(func(req *http.Request) (*http.Response, error) {
+			traceparents = append(traceparents, req.Header.Get("traceparent"))
+			if len(traceparents) == 1 {
+				return &http.Response{StatusCode: http.StatusServiceUnavailable, Body: http.NoBody}, nil
+			}
+			return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody}, nil
+		})),
+	)
+
+	tracer := heimdalltest.NewRecordingTracer()
+	client.AddPlugin(plugins.NewTracing(tracer))
+
+	headers := http.Header{"traceparent": {"stale"}}
+	_, err := client.Get("http://localhost/orders", headers)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"stale"}, headers["traceparent"], "the caller's headers should be left untouched")
+
+	spans := tracer.Spans()
+	require.Len(t, spans, 3)
+	call, first, second := spans[0], spans[1], spans[2]
+
+	assert.Equal(t, "HTTP GET", call.Name)
+	assert.False(t, call.Parent.IsValid())
+	assert.Equal(t, 1, call.Attributes["heimdall.retry_count"])
+	assert.Equal(t, http.StatusOK, call.Attributes["http.response.status_code"])
+	assert.True(t, call.Ended)
+
+	for i, attempt := range []heimdalltest.RecordedSpan{first, second} {
+		assert.Equal(t, "HTTP GET attempt", attempt.Name)
+		assert.Equal(t, call.SpanContext, attempt.Parent)
+		assert.Equal(t, "http://localhost/orders", attempt.Attributes["url.full"])
+		assert.Equal(t, attempt.SpanContext.Traceparent(), traceparents[i])
+		assert.True(t, attempt.Ended)
+	}
+	assert.Equal(t, http.StatusServiceUnavailable, first.Attributes["http.response.status_code"])
+	assert.Equal(t, "503", first.Attributes["error.type"])
+	assert.NotContains(t, first.Attributes, "http.request.resend_count")
+	assert.Equal(t, 1, second.Attributes["http.request.resend_count"])
+}
+
+func TestTracingPluginRecordsErrors(t *testing.T) {
+	t.Parallel()
+
+	errBoom := errors.New("boom")
+	client := httpclient.NewClient(httpclient.WithHTTPClient(doerFunc(func(*http.Request) (*http.Response, error) {
+		return nil, errBoom
+	})))
+
+	tracer := heimdalltest.NewRecordingTracer()
+	client.AddPlugin(plugins.NewTracing(tracer))
+
+	_, err := client.Get("http://localhost", http.Header{})
+	require.ErrorIs(t, err, errBoom)
+
+	spans := tracer.Spans()
+	require.Len(t, spans, 2)
+	for _, span := range spans {
+		require.Len(t, span.Errors, 1)
+		assert.ErrorIs(t, span.Errors[0], errBoom)
+		assert.True(t, span.Ended)
+	}
+}
+
+func TestTracingPluginReplacesTraceContextHeaders(t *testing.T) {
+	t.Parallel()
+
+	var traceparents, tracestates []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparents = r.Header.Values(plugins.TraceparentHeader)
+		tracestates = r.Header.Values(plugins.TracestateHeader)
+	}))
+	defer server.Close()
+
+	tracer := heimdalltest.NewRecordingTracer()
+	client := httpclient.NewClient()
+	client.AddPlugin(plugins.NewTracing(tracer))
+
+	headers := http.Header{"traceparent": {"stale"}, "Tracestate": {"vendor=stale"}}
+	response, err := client.Get(server.URL, headers)
+	require.NoError(t, err)
+	_ = response.Body.Close()
+
+	spans := tracer.Spans()
+	require.Len(t, spans, 2)
+	assert.Equal(t, []string{spans[1].SpanContext.Traceparent()}, traceparents, "a single traceparent should be sent")
+	assert.Empty(t, tracestates)
+	assert.Equal(t, http.Header{"traceparent": {"stale"}, "Tracestate": {"vendor=stale"}}, headers)
+}
+
+func TestTracingPluginRecordsOpenCircuits(t *testing.T) {
+	t.Parallel()
+
+	client := circuitbreaker.NewClient(
+		circuitbreaker.WithClient(httpclient.NewClient(httpclient.WithHTTPClient(doerFunc(func(*http.Request) (*http.Response, error) {
+			return &http.Response{StatusCode: http.StatusInternalServerError, Body: http.NoBody}, nil
+		})))),
+		circuitbreaker.WithCountWindow(1),
+		circuitbreaker.WithMinimumCalls(1),
+		circuitbreaker.WithOpenDuration(time.Minute),
+	)
+
+	tracer := heimdalltest.NewRecordingTracer()
+	client.AddPlugin(plugins.NewTracing(tracer))
+
+	_, err := client.Get("http://localhost", http.Header{})
+	require.NoError(t, err)
+	tracer.Reset()
+
+	_, err = client.Get("http://localhost", http.Header{})
+	require.ErrorIs(t, err, circuitbreaker.ErrCircuitOpen)
+
+	spans := tracer.Spans()
+	require.Len(t, spans, 1, "a rejected call has no attempt span")
+	assert.Equal(t, true, spans[0].Attributes["heimdall.circuit_open"])
+	assert.Equal(t, 0, spans[0].Attributes["heimdall.retry_count"])
+	require.Len(t, spans[0].Errors, 1)
+	assert.ErrorIs(t, spans[0].Errors[0], circuitbreaker.ErrCircuitOpen)
+	assert.True(t, spans[0].Ended)
+}
@@ -7,6 +7,22 @@ type Retriable interface {
 	NextInterval(retry int) time.Duration
 }
 
+// StatefulRetriable is implemented by retriers whose next interval depends on the progress of the call.
+// Like a Backoff, a retrier may return Stop to end retries.
+type StatefulRetriable interface {
+	Retriable
+	NextIntervalState(state BackoffState) time.Duration
+}
+
+// NextInterval returns the next interval of the retrier, passing the progress of the call to stateful retriers
+func NextInterval(r Retriable, state BackoffState) time.Duration {
+	if sr, ok := r.(StatefulRetriable); ok {
+		return sr.NextIntervalState(state)
+	}
+
+	return r.NextInterval(state.Retry)
+}
+
 // RetriableFunc is an adapter to allow the use of ordinary functions
 // as a Retriable
 type RetriableFunc func(retry int) time.Duration
@@ -37,6 +53,11 @@ func (r *retrier) NextInterval(retry int) time.Duration {
 	return r.backoff.Next(retry)
 }
 
+// NextIntervalState returns next retriable time, passing the progress of the call to stateful backoffs
+func (r *retrier) NextIntervalState(state BackoffState) time.Duration {
+	return nextState(r.backoff, state)
+}
+
 type noRetrier struct {
 }
 
@@ -0,0 +1,142 @@
+package heimdall
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"slices"
+	"syscall"
+)
+
+// IdempotencyKeyHeader is the header carrying the key which lets a server deduplicate retried requests.
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// RetryPolicy decides whether a completed attempt should be retried, along with a short reason for the decision.
+// Exactly one of response and err is non-nil.
+type RetryPolicy func(ctx context.Context, request *http.Request, response *http.Response, err error) (retry bool, reason string)
+
+// DefaultRetryPolicy returns the policy used by heimdall clients unless one is configured.
+// It retries every error, every 5xx response and responses with one of the given status codes.
+func DefaultRetryPolicy(retryableCodes ...int) RetryPolicy {
+	codes := slices.Clone(retryableCodes)
+	slices.Sort(codes)
+
+	return func(_ context.Context, _ *http.Request, response *http.Response, err error) (bool, string) {
+		if err != nil {
+			return true, "request failed"
+		}
+
+		if _, ok := slices.BinarySearch(codes, response.StatusCode); ok ||
+			response.StatusCode >= http.StatusInternalServerError {
+			return true, statusReason(response.StatusCode)
+		}
+
+		return false, ""
+	}
+}
+
+// RetryOnStatusCodes returns a policy which retries responses with one of the given status codes only.
+func RetryOnStatusCodes(statusCodes ...int) RetryPolicy {
+	return func(_ context.Context, _ *http.Request, response *http.Response, _ error) (bool, string) {
+		if response != nil && slices.Contains(statusCodes, response.StatusCode) {
+			return true, statusReason(response.StatusCode)
+		}
+
+		return false, ""
+	}
+}
+
+// RetryOnConnectionErrors returns a policy which retries connection resets and network timeouts only.
+func RetryOnConnectionErrors() RetryPolicy {
+	return func(_ context.Context, _ *http.Request, _ *http.Response, err error) (bool, string) {
+		if err == nil {
+			return false, ""
+		}
+
+		if errors.Is(err, syscall.ECONNRESET) {
+			return true, "connection reset"
+		}
+
+		var netErr net.Error
+		if errors.As(err, &netErr) && netErr.Timeout() {
+			return true, "timeout"
+		}
+
+		return false, ""
+	}
+}
+
+// RetryOnHeader returns a policy which retries responses carrying the given header.
+// If values are given, the header must also have one of them.
+func RetryOnHeader(name string, values ...string) RetryPolicy {
+	return func(_ context.Context, _ *http.Request, response *http.Response, _ error) (bool, string) {
+		if response == nil {
+			return false, ""
+		}
+
+		got, ok := response.Header[http.CanonicalHeaderKey(name)]
+		if !ok {
+			return false, ""
+		}
+
+		if len(values) == 0 || slices.ContainsFunc(got, func(v string) bool { return slices.Contains(values, v) }) {
+			return true, fmt.Sprintf("header %s", name)
+		}
+
+		return false, ""
+	}
+}
+
+// NeverRetryStatusCodes wraps a policy so that responses with one of the given status codes are never retried,
+// e.g. 501 Not Implemented or 505 HTTP Version Not Supported, which won't go away on retry.
+func NeverRetryStatusCodes(policy RetryPolicy, statusCodes ...int) RetryPolicy {
+	return func(ctx context.Context, request *http.Request, response *http.Response, err error) (bool, string) {
+		if response != nil && slices.Contains(statusCodes, response.StatusCode) {
+			return false, fmt.Sprintf("status code %d is never retried", response.StatusCode)
+		}
+
+		return policy(ctx, request, response, err)
+	}
+}
+
+// AnyRetryPolicy returns a policy which retries if any of the given policies does.
+// The reason of the first policy that retries is returned.
+func AnyRetryPolicy(policies ...RetryPolicy) RetryPolicy {
+	return func(ctx context.Context, request *http.Request, response *http.Response, err error) (bool, string) {
+		for _, policy := range policies {
+			if retry, reason := policy(ctx, request, response, err); retry {
+				return true, reason
+			}
+		}
+
+		return false, ""
+	}
+}
+
+// IdempotentRetryPolicy wraps a policy so that requests with a non-idempotent method (e.g. POST, PATCH)
+// are only retried when they carry an Idempotency-Key header.
+func IdempotentRetryPolicy(policy RetryPolicy) RetryPolicy {
+	return func(ctx context.Context, request *http.Request, response *http.Response, err error) (bool, string) {
+		if request != nil && !IsIdempotentMethod(request.Method) && request.Header.Get(IdempotencyKeyHeader) == "" {
+			return false, fmt.Sprintf("%s request without %s", request.Method, IdempotencyKeyHeader)
+		}
+
+		return policy(ctx, request, response, err)
+	}
+}
+
+// IsIdempotentMethod reports whether requests with the given method can be safely repeated, as defined by RFC 9110.
+func IsIdempotentMethod(method string) bool {
+	switch method {
+	case "", http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete:
+		return true
+	default:
+		return false
+	}
+}
+
+func statusReason(statusCode int) string {
+	return fmt.Sprintf("status code %d", statusCode)
+}
@@ -0,0 +1,186 @@
+package heimdall
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+var _ net.Error = timeoutError{}
+
+func statusResponse(statusCode int) *http.Response {
+	return &http.Response{StatusCode: statusCode, Header: http.Header{}}
+}
+
+func TestDefaultRetryPolicy(t *testing.T) {
+	t.Parallel()
+
+	policy := DefaultRetryPolicy(http.StatusConflict)
+	ctx := context.Background()
+
+	retry, reason := policy(ctx, nil, nil, errors.New("boom"))
+	assert.True(t, retry)
+	assert.Equal(t, "request failed", reason)
+
+	retry, reason = policy(ctx, nil, statusResponse(http.StatusBadGateway), nil)
+	assert.True(t, retry)
+	assert.Equal(t, "status code 502", reason)
+
+	retry, _ = policy(ctx, nil, statusResponse(http.StatusConflict), nil)
+	assert.True(t, retry)
+
+	retry, _ = policy(ctx, nil, statusResponse(http.StatusBadRequest), nil)
+	assert.False(t, retry)
+
+	retry, _ = policy(ctx, nil, statusResponse(http.StatusOK), nil)
+	assert.False(t, retry)
+}
+
+func TestRetryOnStatusCodes(t *testing.T) {
+	t.Parallel()
+
+	policy := RetryOnStatusCodes(http.StatusConflict)
+	ctx := context.Background()
+
+	retry, reason := policy(ctx, nil, statusResponse(http.StatusConflict), nil)
+	assert.True(t, retry)
+	assert.Equal(t, "status code 409", reason)
+
+	retry, _ = policy(ctx, nil, statusResponse(http.StatusInternalServerError), nil)
+	assert.False(t, retry)
+
+	retry, _ = policy(ctx, nil, nil, errors.New("boom"))
+	assert.False(t, retry)
+}
+
+func TestRetryOnConnectionErrors(t *testing.T) {
+	t.Parallel()
+
+	policy := RetryOnConnectionErrors()
+	ctx := context.Background()
+
+	reset := &url.Error{Op: "Get", URL: "http://localhost", Err: &net.OpError{Op: "read", Err: syscall.ECONNRESET}}
+	retry, reason := policy(ctx, nil, nil, reset)
+	assert.True(t, retry)
+	assert.Equal(t, "connection reset", reason)
+
+	retry, reason = policy(ctx, nil, nil, fmt.Errorf("wrapped: %w", timeoutError{}))
+	assert.True(t, retry)
+	assert.Equal(t, "timeout", reason)
+
+	retry, _ = policy(ctx, nil, nil, errors.New("unsupported protocol scheme"))
+	assert.False(t, retry)
+
+	retry, _ = policy(ctx, nil, statusResponse(http.StatusServiceUnavailable), nil)
+	assert.False(t, retry)
+}
+
+func TestRetryOnHeader(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	response := statusResponse(http.StatusConflict)
+	response.Header.Set("X-Retryable", "true")
+
+	retry, reason := RetryOnHeader("x-retryable")(ctx, nil, response, nil)
+	assert.True(t, retry)
+	assert.Equal(t, "header x-retryable", reason)
+
+	retry, _ = RetryOnHeader("X-Retryable", "true")(ctx, nil, response, nil)
+	assert.True(t, retry)
+
+	retry, _ = RetryOnHeader("X-Retryable", "false")(ctx, nil, response, nil)
+	assert.False(t, retry)
+
+	retry, _ = RetryOnHeader("X-Other")(ctx, nil, response, nil)
+	assert.False(t, retry)
+
+	retry, _ = RetryOnHeader("X-Retryable")(ctx, nil, nil, errors.New("boom"))
+	assert.False(t, retry)
+}
+
+func TestNeverRetryStatusCodes(t *testing.T) {
+	t.Parallel()
+
+	policy := NeverRetryStatusCodes(DefaultRetryPolicy(), http.StatusNotImplemented, http.StatusHTTPVersionNotSupported)
+	ctx := context.Background()
+
+	retry, reason := policy(ctx, nil, statusResponse(http.StatusNotImplemented), nil)
+	assert.False(t, retry)
+	assert.Equal(t, "status code 501 is never retried", reason)
+
+	retry, _ = policy(ctx, nil, statusResponse(http.StatusHTTPVersionNotSupported), nil)
+	assert.False(t, retry)
+
+	retry, _ = policy(ctx, nil, statusResponse(http.StatusServiceUnavailable), nil)
+	assert.True(t, retry)
+}
+
+func TestAnyRetryPolicy(t *testing.T) {
+	t.Parallel()
+
+	policy := AnyRetryPolicy(RetryOnConnectionErrors(), RetryOnStatusCodes(http.StatusConflict))
+	ctx := context.Background()
+
+	retry, reason := policy(ctx, nil, statusResponse(http.StatusConflict), nil)
+	assert.True(t, retry)
+	assert.Equal(t, "status code 409", reason)
+
+	retry, _ = policy(ctx, nil, nil, timeoutError{})
+	assert.True(t, retry)
+
+	retry, _ = policy(ctx, nil, statusResponse(http.StatusInternalServerError), nil)
+	assert.False(t, retry)
+
+	retry, _ = AnyRetryPolicy()(ctx, nil, nil, errors.New("boom"))
+	assert.False(t, retry)
+}
+
+func TestIdempotentRetryPolicy(t *testing.T) {
+	t.Parallel()
+
+	policy := IdempotentRetryPolicy(DefaultRetryPolicy())
+	ctx := context.Background()
+	response := statusResponse(http.StatusServiceUnavailable)
+
+	get, _ := http.NewRequest(http.MethodGet, "http://localhost", nil)
+	retry, _ := policy(ctx, get, response, nil)
+	assert.True(t, retry)
+
+	post, _ := http.NewRequest(http.MethodPost, "http://localhost", nil)
+	retry, reason := policy(ctx, post, response, nil)
+	assert.False(t, retry)
+	assert.Equal(t, "POST request without Idempotency-Key", reason)
+
+	post.Header.Set(IdempotencyKeyHeader, "key")
+	retry, _ = policy(ctx, post, response, nil)
+	assert.True(t, retry)
+
+	patch, _ := http.NewRequest(http.MethodPatch, "http://localhost", nil)
+	retry, _ = policy(ctx, patch, nil, errors.New("boom"))
+	assert.False(t, retry)
+}
+
+func TestIsIdempotentMethod(t *testing.T) {
+	t.Parallel()
+
+	for _, method := range []string{"", http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete} {
+		assert.True(t, IsIdempotentMethod(method), method)
+	}
+	for _, method := range []string{http.MethodPost, http.MethodPatch, http.MethodConnect} {
+		assert.False(t, IsIdempotentMethod(method), method)
+	}
+}
@@ -48,3 +48,24 @@ func TestNoRetrier(t *testing.T) {
 	nextInterval := noRetrier.NextInterval(1)
 	assert.Equal(t, time.Duration(0), nextInterval)
 }
+
+func TestNextIntervalPassesStateToStatefulBackoffs(t *testing.T) {
+	t.Parallel()
+
+	retrier := NewRetrier(NewDecorrelatedJitterBackoff(10*time.Millisecond, time.Second))
+
+	for range 1000 {
+		interval := NextInterval(retrier, BackoffState{Retry: 5, Previous: 20 * time.Millisecond})
+		assert.True(t, 10*time.Millisecond <= interval && interval <= 60*time.Millisecond, interval)
+	}
+}
+
+func TestNextIntervalWithStatelessRetriers(t *testing.T) {
+	t.Parallel()
+
+	retrier := NewRetrier(NewConstantBackoff(10*time.Millisecond, 0))
+	assert.Equal(t, 10*time.Millisecond, NextInterval(retrier, BackoffState{Retry: 1, Previous: time.Second}))
+
+	retrierFunc := NewRetrierFunc(func(retry int) time.Duration { return time.Duration(retry) * time.Millisecond })
+	assert.Equal(t, 3*time.Millisecond, NextInterval(retrierFunc, BackoffState{Retry: 3}))
+}